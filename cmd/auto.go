@@ -0,0 +1,165 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/viper"
+)
+
+// errNoBump the commits since the last tag do not call for a bump.
+var errNoBump = errors.New("no commits call for a bump")
+
+// autoCommit is a commit and the bump it calls for.
+type autoCommit struct {
+	vgit.Commit
+	Level string
+}
+
+// autoBump works out the bump level from the Conventional Commits
+// since an entry's last tag.
+type autoBump struct {
+	// Since is the tag, "" for the whole history.
+	Since     string
+	Commits   []autoCommit
+	Others    int
+	ZeroMajor bool
+}
+
+// conventionalCommits reads and classifies the commits since the last
+// tag of an entry.
+func conventionalCommits(entry string) (*autoBump, error) {
+	var cs ConventionalSettings
+	if err := viper.UnmarshalKey(CONVCOMM, &cs); err != nil {
+		return nil, fmt.Errorf("%s; %s", CONVCOMM, err)
+	}
+	types := map[string]string{"feat": "minor", "fix": "patch", "perf": "patch"}
+	for t, l := range cs.Types {
+		if _, ok := ventry.BumpRank(l); !ok {
+			return nil, usageErrorf("%s.types.%s; %q is not one of major, minor, patch or none", CONVCOMM, t, l)
+		}
+		types[t] = l
+	}
+
+	r, err := gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	a := &autoBump{ZeroMajor: cs.ZeroMajor}
+	glob, _, _, err := tagPattern(entry)
+	if err != nil {
+		return nil, err
+	}
+	d, err := r.Describe(glob)
+	switch {
+	case err == nil:
+		a.Since = d.Tag
+	case !errors.Is(err, vgit.ErrNoTag):
+		return nil, err
+	}
+	specs, err := entryPathspecs(r, entry)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := r.Log(a.Since, specs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		cc, ok := vgit.ParseConventional(c.Message)
+		level := types[cc.Type]
+		if cc.Breaking {
+			level = "major"
+		}
+		if rank, _ := ventry.BumpRank(level); !ok || rank == 0 {
+			a.Others++
+			continue
+		}
+		a.Commits = append(a.Commits, autoCommit{Commit: c, Level: level})
+	}
+	return a, nil
+}
+
+// highest returns the largest bump the commits call for.
+func (a *autoBump) highest() string {
+	var level string
+	for _, c := range a.Commits {
+		r, _ := ventry.BumpRank(c.Level)
+		if cur, _ := ventry.BumpRank(level); r > cur {
+			level = c.Level
+		}
+	}
+	return level
+}
+
+// zeroMajor reports whether a major bump of cur is made a minor one.
+func (a *autoBump) zeroMajor(cur ventry.Vers) bool {
+	return cur.Major == 0 && !a.ZeroMajor
+}
+
+// level returns the bump for the current version, "" for none.  Below
+// 1.0.0 a breaking change only bumps the minor number unless ZeroMajor
+// is set.
+func (a *autoBump) level(cur ventry.Vers) string {
+	level := a.highest()
+	if level == "major" && a.zeroMajor(cur) {
+		level = "minor"
+	}
+	return level
+}
+
+// explain writes which commits drove the decision.
+func (a *autoBump) explain(w io.Writer, entry string, cur ventry.Vers) {
+	since := a.Since
+	if len(since) == 0 {
+		since = "the first commit (no tag)"
+	}
+	fmt.Fprintf(w, "%s: %d commit(s) since %s\n", entry, len(a.Commits)+a.Others, since)
+	for _, c := range a.Commits {
+		fmt.Fprintf(w, "  %-5s  %s %s\n", c.Level, c.Hash, subject(c.Message))
+	}
+	if a.Others != 0 {
+		fmt.Fprintf(w, "  %d other commit(s) do not call for a bump\n", a.Others)
+	}
+	switch level := a.highest(); {
+	case len(level) == 0:
+		fmt.Fprintf(w, "%s: no bump\n", entry)
+	case level == "major" && a.zeroMajor(cur):
+		fmt.Fprintf(w, "%s: minor bump (breaking change below 1.0.0)\n", entry)
+	default:
+		fmt.Fprintf(w, "%s: %s bump\n", entry, level)
+	}
+}
+
+// subject returns the first line of a commit message.
+func subject(msg string) string {
+	for i, c := range msg {
+		if c == '\n' {
+			return msg[:i]
+		}
+	}
+	return msg
+}
@@ -0,0 +1,88 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBumpAuto(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		version string
+		tag     bool
+		commits []string
+		want    string
+	}{
+		{"fix", "", "1.2.0", true, []string{"fix: a", "docs: b"}, "v1.2.1"},
+		{"feat and fix", "", "1.2.0", true, []string{"fix: a", "feat(api): b", "fix: c"}, "v1.3.0"},
+		{"bang", "", "1.2.0", true, []string{"fix: a", "feat!: b"}, "v2.0.0"},
+		{"footer", "", "1.2.0", true, []string{"fix: a\n\nBREAKING CHANGE: gone"}, "v2.0.0"},
+		{"nothing to bump", "", "1.2.0", true, []string{"docs: a", "chore: b", "not conventional"}, "v1.2.0"},
+		{"no commits", "", "1.2.0", true, nil, "v1.2.0"},
+		{"no tag", "", "1.2.0", false, []string{"feat: a"}, "v1.3.0"},
+		{"zero major", "", "0.3.1", true, []string{"feat!: a"}, "v0.4.0"},
+		{"zero major feat", "", "0.3.1", true, []string{"feat: a"}, "v0.4.0"},
+		{"zero major set", "conventional:\n  zero-major: true\n", "0.3.1", true, []string{"feat!: a"}, "v1.0.0"},
+		{"mapped type", "conventional:\n  types:\n    refactor: patch\n", "1.2.0", true, []string{"refactor: a"}, "v1.2.1"},
+		{"type mapped to none", "conventional:\n  types:\n    fix: none\n", "1.2.0", true, []string{"fix: a"}, "v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := gitSandbox(t)
+			defer s.close()
+			s.write(".vers.yaml", tt.config)
+			s.vers("init", "-f", "v.yaml", "api", tt.version)
+			s.commit("chore: start")
+			if tt.tag {
+				s.vers("tag", "-f", "v.yaml", "api")
+			}
+			for _, msg := range tt.commits {
+				s.git("commit", "-q", "--allow-empty", "-m", msg)
+			}
+			s.vers("bump", "-f", "v.yaml", "api", "--auto")
+			if got := strings.TrimSpace(s.vers("get", "-f", "v.yaml", "-o", "str", "api")); got != tt.want {
+				t.Errorf("after bump --auto api is %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("level and auto", func(t *testing.T) {
+		s := gitSandbox(t)
+		defer s.close()
+		s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+		if _, code := runVers(t, "bump", "-f", "v.yaml", "api", "minor", "--auto"); code != exitUsage {
+			t.Errorf("bump with a level and --auto exited %d, want %d", code, exitUsage)
+		}
+	})
+	t.Run("bad type level", func(t *testing.T) {
+		s := gitSandbox(t)
+		defer s.close()
+		s.write(".vers.yaml", "conventional:\n  types:\n    fix: huge\n")
+		s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+		s.commit("chore: start")
+		if _, code := runVers(t, "bump", "-f", "v.yaml", "api", "--auto"); code != exitUsage {
+			t.Errorf("bump --auto with fix: huge exited %d, want %d", code, exitUsage)
+		}
+	})
+}
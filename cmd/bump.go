@@ -23,29 +23,100 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/apex/log"
 	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var ( // bumpCmd represents the bump command
 	bumpCmd = &cobra.Command{
-		Use:   "bump",
+		Use:   "bump [entry [major|minor|patch]]",
 		Short: "increment either major, minor or patch version number",
-		Long:  "increment either major, minor or patch version number",
+		Long: `increment either major, minor or patch version number
+
+  vers bump api minor
+  vers bump -e api -i minor
+  vers bump --changed minor
+
+The entry's search and replace rules in the project config are applied
+along with the bump, to every file or (if any fails) to none:
+
+  entries:
+    api:
+      replace:
+        - glob: README.md
+          search: "badge/version-{{.Old}}-"
+          replace: "badge/version-{{.New}}-"
+        - glob: "include/*.h"
+          search: '#define API_VERSION "[^"]*"'
+          replace: '#define API_VERSION "{{.New}}"'
+          regex: true
+
+With --auto the level is worked out from the Conventional Commits since
+the entry's last tag (see vers tag): a breaking change ("feat!:" or a
+BREAKING CHANGE footer) is major, or minor below 1.0.0 unless
+conventional.zero-major is set, "feat" is minor and "fix" and "perf"
+patch, more types are mapped in the project config:
+
+  conventional:
+    types:
+      refactor: patch
+      feat: minor
+
+The commits that decided it are listed on stderr, with none that call
+for a bump nothing changes.
+
+With --changed every entry vers changed lists is bumped, each by the
+level given or (with --auto) its own commits.
+
+The entries depending on it (see vers deps) are bumped too, as the
+cascade policy in the project config says (by default major and minor
+bumps give them a patch bump), unless --no-cascade is given:
+
+  cascade:
+    major: minor
+    minor: patch
+    patch: none
+
+Every member of a group (see vers group) is bumped with the entry, and
+"@group" bumps them all, by the largest level any of them gets.
+
+With --write-changelog the new version's section is prepended to the
+changelog (see vers changelog).
+
+With --tag the work tree must be clean, the bumped files are committed
+and the commit tagged as vers tag would.`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if viper.GetBool(DEBUG) {
-				log.SetLevel(log.DebugLevel)
+			n, level := 2, 1
+			if viper.GetBool(CHANGED) {
+				n, level = 1, 0
+			}
+			if err := cobra.MaximumNArgs(n)(cmd, args); err != nil {
+				return usageErrorf("%s", err)
 			}
 			if len(viper.GetString(VFILE)) == 0 {
-				return fmt.Errorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+				return usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
 			}
-			if len(viper.GetString(ENTRY)) == 0 {
-				return fmt.Errorf("you must supply the entry name (--%s)", ENTRY)
+			switch {
+			case viper.GetBool(CHANGED) && explicit(ENTRY):
+				return usageErrorf("give the entry or --%s, not both", CHANGED)
+			case !viper.GetBool(CHANGED) && len(entryArg(args)) == 0:
+				return usageErrorf("you must supply the entry name (--%s)", ENTRY)
 			}
-			switch what := viper.GetString(BUMP); what {
+			if len(args) > level && explicit(BUMP) && args[level] != viper.GetString(BUMP) {
+				return usageErrorf("give the bump level as an argument or with --%s, not both", BUMP)
+			}
+			if viper.GetBool(AUTO) {
+				if len(bumpArg(args)) != 0 {
+					return usageErrorf("give the bump level or --%s, not both", AUTO)
+				}
+				return nil
+			}
+			switch what := bumpArg(args); what {
 			case "major":
 				fallthrough
 			case "minor":
@@ -53,9 +124,10 @@ var ( // bumpCmd represents the bump command
 			case "patch":
 				return nil
 			}
-			return errors.New("valid values for bump is one of the following: `major,minor,patch`")
+			return usageErrorf("valid values for bump is one of the following: `major,minor,patch`")
 		},
-		Run: bump,
+		PreRun: bindFlags,
+		RunE:   bump,
 	}
 )
 
@@ -64,19 +136,256 @@ func init() {
 	bumpCmd.Flags().StringP(BUMP, "i", "", "Increamt value (one of 'major,minor or patch')")
 	viper.BindPFlag(BUMP, bumpCmd.Flags().Lookup(BUMP))
 
+	bumpCmd.Flags().Bool(AUTO, false, "work the level out from the Conventional Commits since the last tag")
+	viper.BindPFlag(AUTO, bumpCmd.Flags().Lookup(AUTO))
+
+	bumpCmd.Flags().Bool(CHANGED, false, "bump the entries with commits since their last tag (see vers changed)")
+	viper.BindPFlag(CHANGED, bumpCmd.Flags().Lookup(CHANGED))
+	bumpCmd.Flags().String(SINCE, "", "with --changed, the ref to look for commits since")
+
+	bumpCmd.Flags().Bool(NOCASCADE, false, "do not bump the entries depending on it")
+	bumpCmd.Flags().Bool(WRITECL, false, "prepend the new version's changelog section (see vers changelog)")
+	bumpCmd.Flags().Bool(SYNC, false, "sync the entry's manifests (see vers sync)")
+	bumpCmd.Flags().Bool(TAG, false, "commit the bump and tag it (see vers tag)")
+	bumpCmd.Flags().BoolP(SIGN, "s", false, "make a GPG signed tag")
+
 	RootCmd.AddCommand(bumpCmd)
 }
 
-func bump(cmd *cobra.Command, args []string) {
+func bump(cmd *cobra.Command, args []string) error {
+
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	// check before the version file is locked, the lock file is new
+	var repo *vgit.Repo
+	if viper.GetBool(TAG) {
+		if repo, err = gitRepo(); err != nil {
+			return err
+		}
+		if err := checkClean(repo); err != nil {
+			return err
+		}
+	}
+	if !viper.GetBool(CHANGED) {
+		_, err := bumpEntry(vs, repo, entryArg(args), bumpArg(args))
+		return err
+	}
+	ents, err := vs.List()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	rows, err := changedEntries(ents.Names(), viper.GetString(SINCE))
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(os.Stderr, "no entries have changed")
+	}
+	// an entry may have been bumped already as a dependent
+	done := make(map[string]bool)
+	for _, r := range rows {
+		if done[r.Entry] {
+			continue
+		}
+		bumped, err := bumpEntry(vs, repo, r.Entry, bumpArg(args))
+		if err != nil {
+			return err
+		}
+		for _, b := range bumped {
+			done[b.Name] = true
+		}
+	}
+	return nil
+}
+
+// bumpEntry bumps an entry (or every member of its group) by what (""
+// with --auto) and, unless --no-cascade, the entries depending on it,
+// committing and tagging them in repo if that is set.
+func bumpEntry(vs ventry.Store, repo *vgit.Repo, entry, what string) ([]ventry.Bumped, error) {
+	// a group's commits, changelog and tag message are its first member's
+	lead, err := leadEntry(vs, entry)
+	if err != nil {
+		return nil, fmt.Errorf("Bump failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	var auto *autoBump
+	if viper.GetBool(AUTO) {
+		if auto, err = conventionalCommits(lead); err != nil {
+			return nil, err
+		}
+	}
+	var cl *changes
+	if viper.GetBool(WRITECL) {
+		if cl, err = readChanges(lead, "", ""); err != nil {
+			return nil, err
+		}
+	}
+	policy, err := cascadePolicy()
+	if err != nil {
+		return nil, err
+	}
+	if err := runHooks("pre-bump", entry, ""); err != nil {
+		return nil, policyErrorf("Bump failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	// the files are written while the version file is locked, and put
+	// back if it can not be written.
+	var (
+		bumped   []ventry.Bumped
+		named    []string
+		deps     = make(map[string][]string)
+		tagnames []string
+	)
+	p := newSyncPlan()
+	err = vs.Update(func(f *ventry.VFile) error {
+		if named, err = f.Resolve(entry); err != nil {
+			return err
+		}
+		old, err := f.Get(lead)
+		if err != nil {
+			return err
+		}
+		if auto != nil {
+			auto.explain(os.Stderr, lead, old)
+			if what = auto.level(old); len(what) == 0 {
+				return errNoBump
+			}
+		}
+		if bumped, err = f.BumpCascade(entry, what, policy); err != nil {
+			return err
+		}
+		done := make(map[string]bool)
+		for _, b := range bumped {
+			done[b.Name] = true
+		}
+		for _, b := range bumped {
+			for _, d := range f.DependsOn(b.Name) {
+				if done[d] {
+					deps[b.Name] = append(deps[b.Name], d)
+				}
+			}
+			if repo != nil {
+				name, err := tagName(repo, b.Name, b.New)
+				if err != nil {
+					return err
+				}
+				tagnames = append(tagnames, name)
+			}
+			if err := p.replace(b.Name, b.Old, b.New); err != nil {
+				return err
+			}
+			if syncWanted() {
+				if err := p.add(b.Name, b.New); err != nil {
+					return err
+				}
+			}
+		}
+		if cl != nil {
+			if err := p.changelog(cl, lead, bumpedVers(bumped, lead)); err != nil {
+				return err
+			}
+		}
+		return p.apply()
+	})
+	if errors.Is(err, errNoBump) {
+		return nil, nil
+	}
+	if err != nil {
+		p.rollback()
+		return nil, fmt.Errorf("Bump failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	own := make(map[string]bool)
+	for _, n := range named {
+		own[n] = true
+	}
+	for _, b := range bumped {
+		switch {
+		case !own[b.Name]:
+			fmt.Printf("%s %s (%s, depends on %s)\n", b.Name, b.New, b.Level, strings.Join(deps[b.Name], ", "))
+		case viper.GetBool(CHANGED) || len(bumped) > 1:
+			fmt.Printf("%s %s\n", b.Name, b.New)
+		default:
+			fmt.Println(b.New)
+		}
+	}
+	if repo != nil {
+		msg, err := entryTemplate(lead, TAGMSG, bumpedVers(bumped, lead))
+		if err != nil {
+			return nil, err
+		}
+		if err := repo.Commit(msg, append([]string{viper.GetString(VFILE)}, p.written...)...); err != nil {
+			return nil, err
+		}
+		for i, b := range bumped {
+			if err := makeTag(repo, tagnames[i], b.Name, b.New); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, b := range bumped {
+		if err := runHooks("post-bump", b.Name, b.New.String()); err != nil {
+			return nil, err
+		}
+	}
+	return bumped, nil
+}
 
-	vp, err := ventry.Open(viper.GetString(VFILE), false)
+// leadEntry returns the entry a bump is named after, the first member
+// for a group.
+func leadEntry(vs ventry.Store, entry string) (string, error) {
+	if !strings.HasPrefix(entry, ventry.GroupPrefix) {
+		return entry, nil
+	}
+	f, err := vs.Snapshot()
 	if err != nil {
-		log.Fatalf("Open failed on %s; %s", viper.GetString(VFILE), err)
+		return "", err
+	}
+	members, err := f.Resolve(entry)
+	if err != nil {
+		return "", err
+	}
+	return members[0], nil
+}
+
+// bumpedVers returns the new version of name.
+func bumpedVers(bumped []ventry.Bumped, name string) ventry.Vers {
+	for _, b := range bumped {
+		if b.Name == name {
+			return b.New
+		}
+	}
+	return ventry.Vers{}
+}
+
+// cascadePolicy returns how bumps cascade to dependents, none with
+// --no-cascade.
+func cascadePolicy() (ventry.Cascade, error) {
+	if viper.GetBool(NOCASCADE) {
+		return ventry.Cascade{}, nil
+	}
+	if !viper.IsSet(CASCADE) {
+		return ventry.DefaultCascade, nil
+	}
+	c := ventry.Cascade(viper.GetStringMapString(CASCADE))
+	for from, to := range c {
+		rf, _ := ventry.BumpRank(from)
+		if rt, ok := ventry.BumpRank(to); rf == 0 || !ok || (rt == 0 && to != "none") {
+			return nil, usageErrorf("%s.%s: %s; the keys are major, minor or patch, the values major, minor, patch or none", CASCADE, from, to)
+		}
+	}
+	return c, nil
+}
+
+// bumpArg returns the bump level given as the second argument (the
+// first with --changed) or --bump.
+func bumpArg(args []string) string {
+	n := 1
+	if viper.GetBool(CHANGED) {
+		n = 0
 	}
-	defer vp.Close()
-	if err = vp.Bump(viper.GetString(ENTRY), viper.GetString(BUMP)); err != nil {
-		log.Infof("Bump failed on %s; %s", viper.GetString(VFILE), err)
-		return
+	if len(args) > n {
+		return args[n]
 	}
-	vp.Print(viper.GetString(ENTRY), "str")
+	return viper.GetString(BUMP)
 }
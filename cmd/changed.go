@@ -0,0 +1,207 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// changedCmd represents the changed command
+	changedCmd = &cobra.Command{
+		Use:   "changed [entry...]",
+		Short: "List the entries with commits since their last tag",
+		Long: `List the entries (all without arguments) with commits touching their
+paths since --since or else their last tag (see vers tag), one per line.
+An entry's paths are globs (git pathspecs) set in the project config,
+relative to it, entries without any are never listed:
+
+  entries:
+    api:
+      paths: ["services/api", "libs/common/**/*.go"]
+    web:
+      paths: ["web"]
+
+  $ vers changed --since main
+  api
+
+-o table, json or yaml also give the tag and the number of commits.
+vers bump --changed bumps exactly these entries.`,
+		Args:   cobra.ArbitraryArgs,
+		PreRun: bindFlags,
+		RunE:   changed,
+	}
+)
+
+func init() {
+	changedCmd.Flags().String(SINCE, "", "ref to look for commits since (default each entry's last tag)")
+
+	RootCmd.AddCommand(changedCmd)
+}
+
+// changedEntry is an entry with commits since Since.
+type changedEntry struct {
+	Entry   string
+	Since   string
+	Commits int
+}
+
+// entryPathspecs returns the git pathspecs of an entry's paths, none
+// if it has no paths.
+func entryPathspecs(r *vgit.Repo, name string) ([]string, error) {
+	es, err := entrySettings(name)
+	if err != nil || len(es.Paths) == 0 {
+		return nil, err
+	}
+	top, err := r.TopLevel()
+	if err != nil {
+		return nil, err
+	}
+	if t, err := filepath.EvalSymlinks(top); err == nil {
+		top = t
+	}
+	return pathspecs(top, es.Paths)
+}
+
+// pathspecs returns the git pathspecs of paths.
+func pathspecs(top string, paths []string) ([]string, error) {
+	var specs []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(projectPath(p))
+		if err != nil {
+			return nil, err
+		}
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+			abs = filepath.Join(dir, filepath.Base(abs))
+		}
+		rel, err := filepath.Rel(top, abs)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, ":(top,glob)"+filepath.ToSlash(rel))
+	}
+	return specs, nil
+}
+
+// changedEntries returns those of names with commits touching their
+// paths since the ref since, or else their last tag.
+func changedEntries(names []string, since string) ([]changedEntry, error) {
+	out := make([]changedEntry, 0)
+	r, err := gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		specs, err := entryPathspecs(r, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(specs) == 0 {
+			continue
+		}
+		from := since
+		if len(from) == 0 {
+			glob, _, _, err := tagPattern(name)
+			if err != nil {
+				return nil, err
+			}
+			d, err := r.Describe(glob)
+			switch {
+			case err == nil:
+				from = d.Tag
+			case !errors.Is(err, vgit.ErrNoTag):
+				return nil, err
+			}
+		}
+		n, err := r.Changes(from, specs...)
+		if err != nil {
+			return nil, err
+		}
+		if n != 0 {
+			out = append(out, changedEntry{Entry: name, Since: from, Commits: n})
+		}
+	}
+	return out, nil
+}
+
+// entryNames returns names, checking they are entries, or all entries
+// if there are none.
+func entryNames(ents ventry.Entries, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return ents.Names(), nil
+	}
+	for _, name := range names {
+		if _, ok := ents[name]; !ok {
+			return nil, &ventry.EntryError{Name: name, Err: ventry.ErrEntryNotFound}
+		}
+	}
+	return names, nil
+}
+
+func changed(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	ents, err := vs.List()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	names, err := entryNames(ents, args)
+	if err != nil {
+		return err
+	}
+	rows, err := changedEntries(names, viper.GetString(SINCE))
+	if err != nil {
+		return err
+	}
+
+	return writeFormatted("str", rows, map[string]func() error{
+		"str": func() error {
+			for _, r := range rows {
+				fmt.Println(r.Entry)
+			}
+			return nil
+		},
+		"table": func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ENTRY\tSINCE\tCOMMITS")
+			for _, r := range rows {
+				since := r.Since
+				if len(since) == 0 {
+					since = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\n", r.Entry, since, r.Commits)
+			}
+			return w.Flush()
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChanged(t *testing.T) {
+	s := gitSandbox(t)
+	defer s.close()
+	for _, d := range []string{"services/api", "libs/common/deep", "web", "docs"} {
+		if err := os.MkdirAll(filepath.Join(s.dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.write(".vers.yaml", `entries:
+  api:
+    paths: ["services/api", "libs/common/**/*.go"]
+    tag-template: "api/v{{version}}"
+  web:
+    paths: ["web"]
+    tag-template: "web/v{{version}}"
+`)
+	s.write("services/api/main.go", "package main\n")
+	s.write("web/index.html", "<p>\n")
+	s.write("docs/README", "docs\n")
+	s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "web", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "cli", "1.0.0")
+	s.commit("start")
+	s.vers("tag", "-f", "v.yaml", "api")
+	s.vers("tag", "-f", "v.yaml", "web")
+
+	changed := func(args ...string) string {
+		t.Helper()
+		return s.vers(append([]string{"changed", "-f", "v.yaml"}, args...)...)
+	}
+	steps := []struct {
+		file string
+		want string
+	}{
+		{"", ""},
+		{"docs/README", ""},
+		{"web/index.html", "web\n"},
+		{"libs/common/notes.txt", "web\n"},
+		{"libs/common/deep/util.go", "api\nweb\n"},
+	}
+	for _, st := range steps {
+		if len(st.file) != 0 {
+			s.write(st.file, "changed\n")
+			s.commit("change " + st.file)
+		}
+		if got := changed(); got != st.want {
+			t.Errorf("after changing %q changed = %q, want %q", st.file, got, st.want)
+		}
+	}
+
+	if got := changed("api"); got != "api\n" {
+		t.Errorf("changed api = %q, want %q", got, "api\n")
+	}
+	if got := changed("cli"); got != "" {
+		t.Errorf("changed cli (no paths) = %q, want nothing", got)
+	}
+	if got := changed("--since", "HEAD~1"); got != "api\n" {
+		t.Errorf("changed --since HEAD~1 = %q, want %q", got, "api\n")
+	}
+	table := changed("-o", "table")
+	for _, want := range []string{"api    api/v1.0.0  1", "web    web/v1.0.0  1"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("changed -o table has no %q:\n%s", want, table)
+		}
+	}
+	if _, code := runVers(t, "changed", "-f", "v.yaml", "nope"); code != exitNotFound {
+		t.Errorf("changed nope exited %d, want %d", code, exitNotFound)
+	}
+
+	s.vers("bump", "-f", "v.yaml", "--changed", "patch")
+	for entry, want := range map[string]string{"api": "v1.0.1", "web": "v1.0.1", "cli": "v1.0.0"} {
+		if got := strings.TrimSpace(s.vers("get", "-f", "v.yaml", "-o", "str", entry)); got != want {
+			t.Errorf("after bump --changed %s is %s, want %s", entry, got, want)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultChangelog is the Markdown template of a changelog section.
+const defaultChangelog = `## {{.Version}} ({{.Date}})
+{{if .Breaking}}
+### Breaking Changes
+
+{{range .Breaking}}- {{if .Scope}}**{{.Scope}}:** {{end}}{{.Description}} ({{.Hash}})
+{{end}}{{end}}{{range .Groups}}
+### {{.Title}}
+
+{{range .Scopes}}{{$scope := .Scope}}{{range .Commits}}- {{if $scope}}**{{$scope}}:** {{end}}{{.Description}} ({{.Hash}})
+{{end}}{{end}}{{end}}`
+
+// changelogTitles are the default section titles, in order.
+var changelogTitles = []struct{ Type, Title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+}
+
+var (
+	// changelogCmd represents the changelog command
+	changelogCmd = &cobra.Command{
+		Use:   "changelog [entry]",
+		Short: "Write release notes from the Conventional Commits",
+		Long: `Render a Markdown changelog section from the Conventional Commits
+between two of the entry's versions (--from, default its last tag, and
+--to, default HEAD), grouped by type and scope.  The section is named
+after --to or else the entry's version in the version file.
+
+  vers changelog api
+  vers changelog api --from 1.2.0 --to 1.3.0
+  vers changelog api --write      # prepend it to CHANGELOG.md
+
+The template and file are set in the project config:
+
+  changelog:
+    file: docs/CHANGELOG.md
+    template-file: .changelog.tmpl
+    titles:
+      docs: Documentation
+
+bump --write-changelog (or write-changelog: true in the config) writes
+the new version's section along with the bump.`,
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: bindFlags,
+		RunE:   changelog,
+	}
+)
+
+func init() {
+	changelogCmd.Flags().String(FROM, "", "version to start after (default the last tag)")
+	changelogCmd.Flags().String(TO, "", "version to end at (default HEAD)")
+	changelogCmd.Flags().Bool(WRITE, false, "prepend the section to the changelog file")
+
+	RootCmd.AddCommand(changelogCmd)
+}
+
+// changelogCommit is a commit as the template sees it.
+type changelogCommit struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// changelogScope is the commits of a type with the same scope.
+type changelogScope struct {
+	Scope   string
+	Commits []changelogCommit
+}
+
+// changelogGroup is the commits of a type.
+type changelogGroup struct {
+	Type   string
+	Title  string
+	Scopes []changelogScope
+}
+
+// changelogData is what the changelog template sees.
+type changelogData struct {
+	Name     string
+	Version  string
+	Semver   string
+	Date     string
+	Previous string
+	Breaking []changelogCommit
+	Groups   []changelogGroup
+}
+
+// changes is the commits between two versions of an entry.
+type changes struct {
+	settings ChangelogSettings
+	from     string
+	to       string
+	date     string
+	commits  []vgit.Commit
+}
+
+// entryTag returns the tag of version s of an entry.
+func entryTag(entry, s string) (string, error) {
+	ve, err := ventry.Parse(s)
+	if err != nil {
+		return "", usageErrorf("%w", err)
+	}
+	if len(ve.Prefix) == 0 {
+		ve.Prefix = entryString(entry, PREFIX)
+	}
+	return entryTemplate(entry, TAGTMPL, ve)
+}
+
+// readChanges reads the commits between the tags of versions from and
+// to of an entry, from "" being its last tag and to "" HEAD.
+func readChanges(entry, from, to string) (*changes, error) {
+	c := &changes{to: "HEAD"}
+	if err := viper.UnmarshalKey(CHANGES, &c.settings); err != nil {
+		return nil, fmt.Errorf("%s; %s", CHANGES, err)
+	}
+	r, err := gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	if len(to) != 0 {
+		if c.to, err = entryTag(entry, to); err != nil {
+			return nil, err
+		}
+	}
+	if len(from) != 0 {
+		if c.from, err = entryTag(entry, from); err != nil {
+			return nil, err
+		}
+	} else {
+		glob, _, _, err := tagPattern(entry)
+		if err != nil {
+			return nil, err
+		}
+		d, err := r.Describe(glob)
+		switch {
+		case err == nil:
+			c.from = d.Tag
+		case !errors.Is(err, vgit.ErrNoTag):
+			return nil, err
+		}
+	}
+	if c.to == "HEAD" {
+		c.date = time.Now().Format("2006-01-02")
+	} else if c.date, err = r.Date(c.to); err != nil {
+		return nil, err
+	}
+	specs, err := entryPathspecs(r, entry)
+	if err != nil {
+		return nil, err
+	}
+	if c.commits, err = r.LogRange(c.from, c.to, specs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// template returns the changelog template.
+func (c *changes) template() (*template.Template, error) {
+	switch {
+	case len(c.settings.Template) != 0:
+		return ventry.NewTemplate(CHANGES, c.settings.Template)
+	case len(c.settings.TemplateFile) != 0:
+		p := projectPath(c.settings.TemplateFile)
+		text, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return ventry.NewTemplate(p, string(text))
+	}
+	return ventry.NewTemplate(CHANGES, defaultChangelog)
+}
+
+// data groups the commits by type and scope, types in the order of
+// the default titles and then by name, scopes by name.
+func (c *changes) data(entry string, ve ventry.Vers) changelogData {
+	titles := make(map[string]string)
+	var order []string
+	for _, t := range changelogTitles {
+		titles[t.Type] = t.Title
+		order = append(order, t.Type)
+	}
+	var extra []string
+	for t, title := range c.settings.Titles {
+		if _, ok := titles[t]; !ok {
+			extra = append(extra, t)
+		}
+		titles[t] = title
+	}
+	sort.Strings(extra)
+	order = append(order, extra...)
+
+	d := changelogData{
+		Name:     entry,
+		Version:  ve.String(),
+		Semver:   ve.Semver(),
+		Date:     c.date,
+		Previous: c.from,
+	}
+	byType := make(map[string]map[string][]changelogCommit)
+	for _, cm := range c.commits {
+		cc, ok := vgit.ParseConventional(cm.Message)
+		if !ok {
+			continue
+		}
+		e := changelogCommit{Hash: cm.Hash, Type: cc.Type, Scope: cc.Scope, Description: cc.Description, Breaking: cc.Breaking}
+		if cc.Breaking {
+			d.Breaking = append(d.Breaking, e)
+		}
+		if len(titles[cc.Type]) == 0 {
+			continue
+		}
+		if byType[cc.Type] == nil {
+			byType[cc.Type] = make(map[string][]changelogCommit)
+		}
+		byType[cc.Type][cc.Scope] = append(byType[cc.Type][cc.Scope], e)
+	}
+	for _, t := range order {
+		scopes, ok := byType[t]
+		if !ok {
+			continue
+		}
+		g := changelogGroup{Type: t, Title: titles[t]}
+		var names []string
+		for s := range scopes {
+			names = append(names, s)
+		}
+		sort.Strings(names)
+		for _, s := range names {
+			g.Scopes = append(g.Scopes, changelogScope{Scope: s, Commits: scopes[s]})
+		}
+		d.Groups = append(d.Groups, g)
+	}
+	return d
+}
+
+// render returns the changelog section for version ve of an entry.
+func (c *changes) render(entry string, ve ventry.Vers) ([]byte, error) {
+	var buf bytes.Buffer
+
+	t, err := c.template()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(&buf, c.data(entry, ve)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// file returns the path of the changelog.
+func (c *changes) file() string {
+	if len(c.settings.File) != 0 {
+		return projectPath(c.settings.File)
+	}
+	return projectPath("CHANGELOG.md")
+}
+
+// prependSection puts a section at the top of a changelog, after its
+// title if it has one.
+func prependSection(data, section []byte) []byte {
+	var head []byte
+	if bytes.HasPrefix(data, []byte("# ")) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			i = len(data)
+			data = append(data, '\n')
+		}
+		head, data = data[:i+1], bytes.TrimLeft(data[i+1:], "\n")
+		head = append(append([]byte{}, head...), '\n')
+	}
+	out := append(head, bytes.TrimRight(section, "\n")...)
+	out = append(out, '\n')
+	if len(data) != 0 {
+		out = append(append(out, '\n'), data...)
+	}
+	return out
+}
+
+// changelog adds the changelog section to the plan.
+func (p *syncPlan) changelog(c *changes, entry string, ve ventry.Vers) error {
+	section, err := c.render(entry, ve)
+	if err != nil {
+		return err
+	}
+	path := c.file()
+	data, err := p.readOrCreate(path)
+	if err != nil {
+		return err
+	}
+	p.files[path] = prependSection(data, section)
+	p.changes = append(p.changes, syncChange{Entry: entry, File: relPath(path), New: ve.String()})
+	return nil
+}
+
+func changelog(cmd *cobra.Command, args []string) error {
+	entry := entryArg(args)
+	if len(entry) == 0 {
+		return usageErrorf("you must supply entry name (--%s)", ENTRY)
+	}
+	c, err := readChanges(entry, viper.GetString(FROM), viper.GetString(TO))
+	if err != nil {
+		return err
+	}
+	var ve ventry.Vers
+	if to := viper.GetString(TO); len(to) != 0 {
+		if ve, err = ventry.Parse(to); err != nil {
+			return usageErrorf("%w", err)
+		}
+		if len(ve.Prefix) == 0 {
+			ve.Prefix = entryString(entry, PREFIX)
+		}
+	} else {
+		vs, err := openStore(false)
+		if err != nil {
+			return err
+		}
+		ve, err = vs.Get(entry)
+		vs.Close()
+		if err != nil {
+			return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+		}
+	}
+	if !viper.GetBool(WRITE) {
+		section, err := c.render(entry, ve)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(section)
+		return err
+	}
+	p := newSyncPlan()
+	if err := p.changelog(c, entry, ve); err != nil {
+		return err
+	}
+	if err := p.apply(); err != nil {
+		return err
+	}
+	fmt.Println(p.changes[0].File)
+	return nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+)
+
+func TestChangelogSection(t *testing.T) {
+	c := &changes{
+		settings: ChangelogSettings{Titles: map[string]string{"docs": "Documentation", "build": "Build"}},
+		from:     "v1.2.0",
+		date:     "2020-01-02",
+		commits: []vgit.Commit{
+			{Hash: "h7", Message: "docs: readme"},
+			{Hash: "h6", Message: "chore: tidy up"},
+			{Hash: "h5", Message: "not conventional"},
+			{Hash: "h4", Message: "fix(web): typo\n\nBREAKING CHANGE: the old form is gone"},
+			{Hash: "h3", Message: "feat(api)!: drop v1"},
+			{Hash: "h2", Message: "fix: off by one"},
+			{Hash: "h1", Message: "feat: add x"},
+			{Hash: "h0", Message: "build: go 1.13"},
+		},
+	}
+	ve, err := ventry.Parse("v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `## v1.3.0 (2020-01-02)
+
+### Breaking Changes
+
+- **web:** typo (h4)
+- **api:** drop v1 (h3)
+
+### Features
+
+- add x (h1)
+- **api:** drop v1 (h3)
+
+### Bug Fixes
+
+- off by one (h2)
+- **web:** typo (h4)
+
+### Build
+
+- go 1.13 (h0)
+
+### Documentation
+
+- readme (h7)
+`
+	got, err := c.render("api", ve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("render:\n%s\nwant:\n%s", got, want)
+	}
+
+	d := c.data("api", ve)
+	if d.Name != "api" || d.Version != "v1.3.0" || d.Semver != "1.3.0" || d.Previous != "v1.2.0" {
+		t.Errorf("data = %+v", d)
+	}
+
+	c.settings.Template = "{{.Name}} {{.Semver}} {{len .Groups}}"
+	if got, err := c.render("api", ve); err != nil || string(got) != "api 1.3.0 4" {
+		t.Errorf("render with a template = %q, %v, want %q", got, err, "api 1.3.0 4")
+	}
+
+	empty := &changes{date: "2020-01-02"}
+	if got, err := empty.render("api", ve); err != nil || string(got) != "## v1.3.0 (2020-01-02)\n" {
+		t.Errorf("render without commits = %q, %v", got, err)
+	}
+}
+
+func TestPrependSection(t *testing.T) {
+	section := "## v1.3.0\n\n- new\n\n"
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "## v1.3.0\n\n- new\n"},
+		{"title", "# Changelog\n", "# Changelog\n\n## v1.3.0\n\n- new\n"},
+		{"title without newline", "# Changelog", "# Changelog\n\n## v1.3.0\n\n- new\n"},
+		{"title and sections", "# Changelog\n\n\n## v1.2.0\n\n- old\n",
+			"# Changelog\n\n## v1.3.0\n\n- new\n\n## v1.2.0\n\n- old\n"},
+		{"no title", "## v1.2.0\n\n- old\n", "## v1.3.0\n\n- new\n\n## v1.2.0\n\n- old\n"},
+	}
+	for _, tt := range tests {
+		if got := string(prependSection([]byte(tt.data), []byte(section))); got != tt.want {
+			t.Errorf("%s: prependSection = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	s := gitSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+	s.write("CHANGELOG.md", "# Changelog\n")
+	s.commit("chore: start")
+	s.vers("tag", "-f", "v.yaml", "api")
+	today := time.Now().Format("2006-01-02")
+
+	// nothing since the tag
+	if out := s.vers("changelog", "-f", "v.yaml", "api"); out != "## v1.2.0 ("+today+")\n" {
+		t.Errorf("changelog of an empty range: %q", out)
+	}
+
+	s.git("commit", "-q", "--allow-empty", "-m", "feat: add x")
+	s.vers("bump", "-f", "v.yaml", "api", "minor", "--write-changelog")
+	s.git("commit", "-q", "-a", "-m", "chore: release")
+	s.vers("tag", "-f", "v.yaml", "api")
+	s.git("commit", "-q", "--allow-empty", "-m", "fix: typo")
+	s.vers("bump", "-f", "v.yaml", "api", "patch", "--write-changelog")
+
+	data, err := ioutil.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n## v1.3.1 (" + today + ")\n\n### Bug Fixes\n\n- typo (" + s.git("rev-parse", "--short=7", "HEAD") + ")\n\n" +
+		"## v1.3.0 (" + today + ")\n\n### Features\n\n- add x (" + s.git("rev-parse", "--short=7", "HEAD~2") + ")\n"
+	if string(data) != want {
+		t.Errorf("CHANGELOG.md:\n%s\nwant:\n%s", data, want)
+	}
+	if n := strings.Count(string(data), "# Changelog"); n != 1 {
+		t.Errorf("CHANGELOG.md has the title %d times", n)
+	}
+
+	// an explicit range of tags
+	out := s.vers("changelog", "-f", "v.yaml", "api", "--from", "1.2.0", "--to", "1.3.0")
+	if !strings.HasPrefix(out, "## v1.3.0 (") || !strings.Contains(out, "- add x (") || strings.Contains(out, "typo") {
+		t.Errorf("changelog --from 1.2.0 --to 1.3.0:\n%s", out)
+	}
+}
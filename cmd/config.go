@@ -0,0 +1,389 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Settings is the project policy we read from the layered .vers
+// config files, each file is checked against it as it is read, e.g.
+//
+//	version-file: versions.yaml
+//	fmt: str
+//	scheme: semver
+//	history: 5
+//	hooks:
+//	  post-bump: ["git add versions.yaml"]
+//	entries:
+//	  api:
+//	    prefix: "api-v"
+//	    paths: ["services/api", "libs/common/*.go"]
+type Settings struct {
+	VersionFile    string `mapstructure:"version-file"`
+	Fmt            string
+	AutoSync       bool   `mapstructure:"auto-sync"`
+	EnvName        string `mapstructure:"env-name"`
+	EnvComp        bool   `mapstructure:"env-components"`
+	Prefix         string
+	Scheme         string
+	History        int
+	Hooks          map[string][]string
+	Ldflags        []LdflagSettings
+	Conventional   ConventionalSettings
+	Cascade        map[string]string
+	Changelog      ChangelogSettings
+	WriteChangelog bool `mapstructure:"write-changelog"`
+	// CommitMsgVersion makes the commit-msg hook require the new
+	// version in the message of a commit changing one.
+	CommitMsgVersion bool `mapstructure:"commit-msg-version"`
+	Entries          map[string]EntrySettings
+}
+
+// EntrySettings are the per entry overrides of the project policy.
+type EntrySettings struct {
+	Prefix  string
+	Scheme  string
+	Sync    []SyncSettings
+	Replace []ReplaceSettings
+	// Paths are globs of the files that belong to the entry (relative
+	// to the project config's directory), see vers changed.
+	Paths []string
+}
+
+// SyncSettings names a manifest file the entry's version is copied
+// into, relative paths are from the project config's directory.
+type SyncSettings struct {
+	File string
+	// Type (json, toml or yaml) and Key default from the file name.
+	Type string
+	Key  string
+	// Format is a template for the value, default "{{.Semver}}".
+	Format string
+}
+
+// ConventionalSettings maps Conventional Commit types onto the bump
+// they call for (major, minor, patch or none) for bump --auto, on top
+// of feat: minor, fix: patch and perf: patch.
+type ConventionalSettings struct {
+	Types map[string]string
+	// ZeroMajor lets a breaking change take a 0.x version to 1.0.0,
+	// otherwise it only bumps the minor number.
+	ZeroMajor bool `mapstructure:"zero-major"`
+}
+
+// ChangelogSettings configure vers changelog.
+type ChangelogSettings struct {
+	// File is the changelog, default CHANGELOG.md.
+	File string
+	// Template or TemplateFile override the Markdown template.
+	Template     string
+	TemplateFile string `mapstructure:"template-file"`
+	// Titles are the section titles of the commit types, on top of
+	// feat, fix, perf and revert; types without one are left out.
+	Titles map[string]string
+}
+
+// ReplaceSettings is a search and replace rule bump applies to the
+// files matching Glob (relative to the project config's directory).
+type ReplaceSettings struct {
+	Glob string
+	// Search and Replace are templates of .Name, .Old and .New (the
+	// versions without their prefix) and .OldVersion and .NewVersion,
+	// by default "{{.Old}}" and "{{.New}}".
+	Search  string
+	Replace string
+	// Regex makes the search a regular expression, the versions in it
+	// are quoted and the replacement may use $1 etc.
+	Regex bool
+}
+
+var (
+	// cfgSources maps a config key to the file that last set it.
+	cfgSources = make(map[string]string)
+	// projectDir is the directory of the project config.
+	projectDir string
+
+	// configCmd represents the config command
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the vers configuration",
+		Long:  "Inspect the vers configuration",
+	}
+
+	// configShowCmd represents the config show command
+	configShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective settings and where they came from",
+		Long: `Show the effective settings and where they came from.
+
+Settings are taken from (highest first) command line flags, environment
+variables, the project config (--config or the nearest .vers file in the
+current directory or its parents), the user config ($HOME/.vers) and
+finally the system config (/etc/h9k/.vers).`,
+		RunE: configShow,
+	}
+)
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	RootCmd.AddCommand(configCmd)
+}
+
+// configLayers returns the config files to merge, lowest precedence
+// first, and the project config (if any).
+func configLayers(explicit string) (layers []string, project string) {
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if len(p) == 0 {
+			return
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if !seen[p] {
+			seen[p] = true
+			layers = append(layers, p)
+		}
+	}
+	add(findConfig("/etc/h9k"))
+	if home, err := os.UserHomeDir(); err == nil {
+		add(findConfig(home))
+	}
+	if len(explicit) != 0 {
+		project = explicit
+	} else if wd, err := os.Getwd(); err == nil {
+		for dir := wd; ; dir = filepath.Dir(dir) {
+			if project = findConfig(dir); len(project) != 0 {
+				break
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	add(project)
+	return layers, project
+}
+
+// projectPath resolves a path from the project config against the
+// directory of that config (or the current directory without one).
+func projectPath(p string) string {
+	if filepath.IsAbs(p) || len(projectDir) == 0 {
+		return p
+	}
+	return filepath.Join(projectDir, p)
+}
+
+// findConfig returns the .vers config file in dir, if there is one.
+func findConfig(dir string) string {
+	for _, ext := range viper.SupportedExts {
+		p := filepath.Join(dir, ".vers."+ext)
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+			return p
+		}
+	}
+	return ""
+}
+
+// mergeConfigLayer reads a single config file into viper, remembering
+// which keys it set, refusing one whose settings are not of the types
+// of Settings.
+func mergeConfigLayer(path string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("%s; %w", path, err)
+	}
+	var s Settings
+	if err := v.Unmarshal(&s); err != nil {
+		return fmt.Errorf("%s; %w", path, err)
+	}
+	for _, k := range v.AllKeys() {
+		cfgSources[k] = path
+	}
+	log.Debugf("mergeConfigLayer(): %s", path)
+	return viper.MergeConfigMap(v.AllSettings())
+}
+
+// flagChanged reports whether the flag bound to key was given on the
+// command line of c or any of its sub commands.
+func flagChanged(c *cobra.Command, key string) bool {
+	if f := c.PersistentFlags().Lookup(key); f != nil && f.Changed {
+		return true
+	}
+	if f := c.LocalNonPersistentFlags().Lookup(key); f != nil && f.Changed {
+		return true
+	}
+	for _, sub := range c.Commands() {
+		if flagChanged(sub, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// configSource reports where the effective value of key comes from.
+func configSource(key string) string {
+	if flagChanged(RootCmd, key) {
+		return "flag"
+	}
+	if _, ok := os.LookupEnv(envKey(key)); ok {
+		return "env"
+	}
+	if src, ok := cfgSources[key]; ok {
+		return src
+	}
+	return "default"
+}
+
+// entryString returns the setting key for entry; an explicit flag or
+// environment variable wins, then the entry's own config, then the
+// project wide value.
+func entryString(entry, key string) string {
+	if src := configSource(key); src == "flag" || src == "env" {
+		return viper.GetString(key)
+	}
+	if k := ENTRIES + "." + entry + "." + key; viper.IsSet(k) {
+		return viper.GetString(k)
+	}
+	return viper.GetString(key)
+}
+
+// entrySettings returns the project settings of an entry.
+func entrySettings(entry string) (EntrySettings, error) {
+	var es EntrySettings
+	if err := viper.UnmarshalKey(ENTRIES+"."+entry, &es); err != nil {
+		return es, fmt.Errorf("%s.%s; %s", ENTRIES, entry, err)
+	}
+	return es, nil
+}
+
+// outFmt returns the requested output format or def.
+func outFmt(def string) string {
+	if f := viper.GetString(FMT); len(f) != 0 {
+		return f
+	}
+	return def
+}
+
+// writeFormatted prints v in the format given by --fmt, or in def when
+// it was not given (the configured fmt is for get).  json and yaml are
+// marshalled from v, the command's own formats (def among them) are
+// written by the matching func in text.
+func writeFormatted(def string, v interface{}, text map[string]func() error) error {
+	f := def
+	if explicit(FMT) {
+		f = viper.GetString(FMT)
+	}
+	if fn, ok := text[f]; ok {
+		return fn()
+	}
+	var (
+		out []byte
+		err error
+	)
+	switch f {
+	case "json":
+		out, err = json.MarshalIndent(v, "", "   ")
+	case "yml":
+		fallthrough
+	case "yaml":
+		out, err = yaml.Marshal(v)
+	default:
+		return fmt.Errorf("%q; %w", f, ventry.ErrUnsupportedFormat)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// renderer returns the output renderer set up from the config.
+func renderer() ventry.Renderer {
+	return ventry.Renderer{
+		Env: ventry.EnvOptions{
+			Name:       viper.GetString(ENVNAME),
+			Components: viper.GetBool(ENVCOMP),
+		},
+	}
+}
+
+// runHooks runs the configured commands for stage (e.g. "pre-bump"),
+// passing the entry details through the environment.  They are kept
+// out of the VERS_ overrides so a hook running vers is not changed by
+// them.
+func runHooks(stage, entry, version string) error {
+	for _, line := range viper.GetStringSlice(HOOKS + "." + stage) {
+		log.Debugf("runHooks(): %s: %s", stage, line)
+		c := exec.Command("sh", "-c", line)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		c.Env = append(os.Environ(),
+			"VERS_HOOK="+stage,
+			"VERS_HOOK_ENTRY="+entry,
+			"VERS_HOOK_VERSION="+version,
+			"VERS_HOOK_FILE="+viper.GetString(VFILE))
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed; %s", stage, line, err)
+		}
+	}
+	return nil
+}
+
+func configShow(cmd *cobra.Command, args []string) error {
+	type setting struct {
+		Value  interface{}
+		Source string
+	}
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+	all := make(map[string]setting)
+	for _, k := range keys {
+		all[k] = setting{Value: viper.Get(k), Source: configSource(k)}
+	}
+
+	return writeFormatted("table", all, map[string]func() error{
+		"table": func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, k := range keys {
+				fmt.Fprintf(w, "%s\t%v\t%s\n", k, all[k].Value, all[k].Source)
+			}
+			return w.Flush()
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookEnvironment(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "-e", "api", "-M", "1", "-m", "0", "-p", "0")
+	s.write(".vers.yaml", "hooks:\n  post-bump: [\"env > hook.env\"]\n")
+	s.vers("bump", "-f", "v.yaml", "-e", "api", "-i", "minor")
+
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, "hook.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := make(map[string]string)
+	for _, kv := range strings.Split(string(data), "\n") {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	want := map[string]string{
+		"VERS_HOOK":         "post-bump",
+		"VERS_HOOK_ENTRY":   "api",
+		"VERS_HOOK_VERSION": "v1.1.0",
+		"VERS_HOOK_FILE":    "v.yaml",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s=%q, want %q", k, env[k], v)
+		}
+	}
+	// these would override the settings of vers run by the hook
+	for _, k := range []string{"VERS_ENTRY", "VERS_VERSION", "VERS_VERSION_FILE"} {
+		if v, ok := env[k]; ok {
+			t.Errorf("hook environment has %s=%q", k, v)
+		}
+	}
+}
+
+func TestConfigSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		ok     bool
+	}{
+		{"empty", "", true},
+		{"policy", `version-file: v.yaml
+fmt: str
+scheme: semver
+history: "5"
+hooks:
+  post-bump: "true"
+entries:
+  api:
+    prefix: api-v
+    paths: [services/api]
+`, true},
+		{"history", "history: lots\n", false},
+		{"hooks", "hooks:\n  post-bump:\n    run: true\n", false},
+		{"entries", "entries:\n  api: [1, 2]\n", false},
+		{"entry prefix", "entries:\n  api:\n    prefix: {v: 1}\n", false},
+	}
+	s := newSandbox(t)
+	defer s.close()
+	defer resetConfig(t)
+	for _, tt := range tests {
+		resetConfig(t)
+		s.write(".vers.yaml", tt.config)
+		if err := mergeConfigLayer(filepath.Join(s.dir, ".vers.yaml")); (err == nil) != tt.ok {
+			t.Errorf("%s: mergeConfigLayer: %v", tt.name, err)
+		}
+	}
+}
+
+func TestWriteFormatted(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+	// the configured fmt is for get, the others keep their own default
+	s.write(".vers.yaml", "fmt: str\n")
+
+	if out := s.vers("-f", "v.yaml", "get", "api"); strings.TrimSpace(out) != "v1.0.0" {
+		t.Errorf("get: %q, want v1.0.0", out)
+	}
+	if out := s.vers("-f", "v.yaml", "list"); !strings.HasPrefix(out, "NAME") {
+		t.Errorf("list: %q, want the table", out)
+	}
+	if out := s.vers("config", "show"); !strings.HasPrefix(out, "KEY") {
+		t.Errorf("config show: %q, want the table", out)
+	}
+	if out := s.vers("-f", "v.yaml", "list", "--fmt", "csv"); !strings.HasPrefix(out, "name,version") {
+		t.Errorf("list --fmt csv: %q", out)
+	}
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal([]byte(s.vers("-f", "v.yaml", "graph", "--fmt", "json")), &nodes); err != nil {
+		t.Errorf("graph --fmt json: %v", err)
+	} else if len(nodes) != 1 || nodes[0]["Name"] != "api" {
+		t.Errorf("graph --fmt json: %v", nodes)
+	}
+	if out := s.vers("-f", "v.yaml", "verify", "--fmt", "yaml"); !strings.Contains(out, "entry: api") {
+		t.Errorf("verify --fmt yaml: %q", out)
+	}
+	if _, code := runVers(t, "-f", "v.yaml", "list", "--fmt", "dot"); code != 6 {
+		t.Errorf("list --fmt dot exit %d, want 6", code)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// copyCmd represents the copy command
+	copyCmd = &cobra.Command{
+		Use:   "copy <entry> <new-entry>",
+		Short: "Copy an entry with its history",
+		Long: `Copy an entry with its history and dependencies to a new entry, which
+is in no group and has no dependents.  An existing entry is only
+replaced with --force (exit 7 without).
+
+Settings for the entry in the project config are not copied.`,
+		Args:   entryPairArgs,
+		PreRun: bindFlags,
+		RunE:   copyEntry,
+	}
+)
+
+func init() {
+	copyCmd.Flags().Bool(FORCE, false, "replace the new entry if it exists")
+
+	RootCmd.AddCommand(copyCmd)
+}
+
+func copyEntry(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Copy(args[0], args[1], viper.GetBool(FORCE))
+	})
+	if err != nil {
+		return fmt.Errorf("copy of %s failed on %s; %w", args[0], viper.GetString(VFILE), err)
+	}
+	warnSettings(args[0], args[1])
+	return nil
+}
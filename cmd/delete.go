@@ -24,7 +24,6 @@ import (
 	"fmt"
 
 	"github.com/apex/log"
-	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,42 +31,44 @@ import (
 var (
 	// deleteCmd represents the delete command
 	deleteCmd = &cobra.Command{
-		Use:   "delete",
+		Use:   "delete [entry]",
 		Short: "delete an entry for version file.",
 		Long:  "delete an entry for version file.",
 		Args: func(cmd *cobra.Command, args []string) error {
-			if viper.GetBool(DEBUG) {
-				log.SetLevel(log.DebugLevel)
-			}
-			if viper.GetBool(DEBUG) {
-				log.SetLevel(log.DebugLevel)
+			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+				return usageErrorf("%s", err)
 			}
 			if len(viper.GetString(VFILE)) == 0 {
-				return fmt.Errorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+				return usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
 			}
-			if len(viper.GetString(ENTRY)) == 0 {
-				return fmt.Errorf("you must supply the entry name (--%s)", ENTRY)
+			if len(entryArg(args)) == 0 {
+				return usageErrorf("you must supply the entry name (--%s)", ENTRY)
 			}
 			return nil
 		},
-		Run: del,
+		RunE: del,
 	}
 )
 
 func init() {
 	RootCmd.AddCommand(deleteCmd)
 }
-func del(cmd *cobra.Command, args []string) {
+func del(cmd *cobra.Command, args []string) error {
 
 	log.Debugf("filename: %s, entry: %s",
 		viper.GetString(VFILE), viper.GetString(ENTRY))
 
-	vp, err := ventry.Open(viper.GetString(VFILE), false)
+	vs, err := openStore(false)
 	if err != nil {
-		log.Fatalf("Open failed on %s; %s", viper.GetString(VFILE), err)
+		return err
+	}
+	defer vs.Close()
+	entry := entryArg(args)
+	if err := runHooks("pre-delete", entry, ""); err != nil {
+		return policyErrorf("delete of %s failed on %s; %w", entry, viper.GetString(VFILE), err)
 	}
-	defer vp.Close()
-	if err = vp.Delete(viper.GetString(ENTRY)); err != nil {
-		log.Infof("delete of %f failed on %s; %s", viper.GetString(VFILE), err)
+	if err = vs.Delete(entry); err != nil {
+		return fmt.Errorf("delete of %s failed on %s; %w", entry, viper.GetString(VFILE), err)
 	}
+	return runHooks("post-delete", entry, "")
 }
@@ -0,0 +1,98 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// depsCmd represents the deps command
+	depsCmd = &cobra.Command{
+		Use:   "deps",
+		Short: "Manage the dependencies between entries",
+		Long: `Manage the dependencies between entries, kept in the version file.
+Bumping an entry bumps those depending on it too (see vers bump), vers
+graph shows them.`,
+	}
+
+	// depsAddCmd represents the deps add command
+	depsAddCmd = &cobra.Command{
+		Use:   "add <entry> <depends-on>...",
+		Short: "Make an entry depend on others",
+		Long: `Make an entry depend on others, refusing a dependency that would make
+a cycle (exit 8).
+
+  vers deps add api proto
+  vers deps add web api proto`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: depsAdd,
+	}
+
+	// depsRmCmd represents the deps rm command
+	depsRmCmd = &cobra.Command{
+		Use:   "rm <entry> <depends-on>...",
+		Short: "Drop dependencies of an entry",
+		Long:  "Drop dependencies of an entry",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  depsRm,
+	}
+)
+
+func init() {
+	depsCmd.AddCommand(depsAddCmd)
+	depsCmd.AddCommand(depsRmCmd)
+	RootCmd.AddCommand(depsCmd)
+}
+
+func depsAdd(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Depend(args[0], args[1:]...)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", viper.GetString(VFILE), err)
+	}
+	return nil
+}
+
+func depsRm(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Undepend(args[0], args[1:]...)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", viper.GetString(VFILE), err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// describeCmd represents the describe command
+	describeCmd = &cobra.Command{
+		Use:   "describe [entry]",
+		Short: "Work out a version from the nearest git tag",
+		Long: `Work out an entry's version from the nearest tag reachable from HEAD
+that matches its tag template (see vers tag).  On a clean checkout of
+the tag that is the tag's version, otherwise the next patch (or the
+tag's pre-release) with the commits since the tag and the commit hash:
+
+  $ vers describe api
+  v1.4.2-dev.7+g3fa2c1d
+
+A work tree with uncommitted changes adds .dirty to the build.  The
+version file is not read, --match overrides the glob the tags must
+match, e.g. "api/v[0-9]*".`,
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: bindFlags,
+		RunE:   describe,
+	}
+
+	// sentinel stands in for the version when we turn a tag template
+	// into a pattern.
+	sentinel   = ventry.Vers{Major: 9999911, Minor: 9999922, Patch: 9999933}
+	sentinelRe = regexp.MustCompile(`9999911|9999922|9999933`)
+	globEscape = strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+)
+
+func init() {
+	describeCmd.Flags().String(MATCH, "", "glob the tags must match (default from the tag template)")
+
+	RootCmd.AddCommand(describeCmd)
+}
+
+// tagPattern returns a glob matching the tags of an entry and the
+// text of its tag names before and after the version.
+func tagPattern(entry string) (glob, head, tail string, err error) {
+	sv := sentinel
+	sv.Prefix = entryString(entry, PREFIX)
+	name, err := entryTemplate(entry, TAGTMPL, sv)
+	if err != nil {
+		return "", "", "", err
+	}
+	loc := sentinelRe.FindAllStringIndex(name, -1)
+	if len(loc) == 0 {
+		return "", "", "", usageErrorf("%s %q has no version in it", TAGTMPL, entryString(entry, TAGTMPL))
+	}
+	head, tail = name[:loc[0][0]], name[loc[len(loc)-1][1]:]
+	return globEscape.Replace(head) + "[0-9]*" + globEscape.Replace(tail), head, tail, nil
+}
+
+func describe(cmd *cobra.Command, args []string) error {
+	entry := entryArg(args)
+	if len(entry) == 0 {
+		return usageErrorf("you must supply entry name (--%s)", ENTRY)
+	}
+	glob, head, tail, err := tagPattern(entry)
+	if err != nil {
+		return err
+	}
+	if m := viper.GetString(MATCH); len(m) != 0 {
+		glob = m
+	}
+	r, err := gitRepo()
+	if err != nil {
+		return err
+	}
+	d, err := r.Describe(glob)
+	if err != nil {
+		return err
+	}
+	ve, err := ventry.Parse(strings.TrimSuffix(strings.TrimPrefix(d.Tag, head), tail))
+	if err != nil {
+		return fmt.Errorf("tag %s; %w", d.Tag, err)
+	}
+	ve.Prefix = entryString(entry, PREFIX)
+	if d.Distance != 0 || d.Dirty {
+		build := "g" + d.Hash
+		if d.Dirty {
+			build += ".dirty"
+		}
+		ve = ve.Dev(d.Distance, build)
+	}
+	return renderer().Render(os.Stdout, ventry.Entries{entry: &ve}, entry, outFmt("str"))
+}
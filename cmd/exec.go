@@ -0,0 +1,144 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/apex/log"
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// execCmd represents the exec command
+	execCmd = &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "Run a command with the versions in its environment",
+		Long: `Run a command with the versions in its environment, e.g.
+
+  vers exec -f versions.yaml -- make release
+  vers exec -f versions.yaml -e api,web -- ./build.sh
+
+Every entry (or those given with --entry, comma separated) is exported
+the same way as 'vers get -o sh' would (see --env-name and
+--env-components).  The version file is read under a shared lock before
+the command starts, and vers exits with the command's exit status (128
+plus the signal number if it was killed, as a shell does).`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return usageErrorf("you must supply the command to run")
+			}
+			return nil
+		},
+		RunE: execute,
+	}
+)
+
+func init() {
+	// everything after the command name belongs to the command
+	execCmd.Flags().SetInterspersed(false)
+
+	RootCmd.AddCommand(execCmd)
+}
+
+// selectEntries returns the entries named in the comma separated list
+// sel (all of them if it is empty).
+func selectEntries(ents ventry.Entries, sel string) (ventry.Entries, error) {
+	if len(sel) == 0 {
+		return ents, nil
+	}
+	out := make(ventry.Entries)
+	for _, name := range strings.Split(sel, ",") {
+		name = strings.TrimSpace(name)
+		ve, ok := ents[name]
+		if !ok {
+			return nil, &ventry.EntryError{Name: name, Err: ventry.ErrEntryNotFound}
+		}
+		out[name] = ve
+	}
+	return out, nil
+}
+
+func execute(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	ents, err := vs.List()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	if ents, err = selectEntries(ents, viper.GetString(ENTRY)); err != nil {
+		return err
+	}
+
+	env := os.Environ()
+	opts := renderer().Env
+	for _, name := range ents.Names() {
+		vars, err := ventry.EnvVars(name, *ents[name], opts)
+		if err != nil {
+			return err
+		}
+		for _, ev := range vars {
+			log.Debugf("execute(): %s=%s", ev.Name, ev.Value)
+			env = append(env, ev.Name+"="+ev.Value)
+		}
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Start(); err != nil {
+		return err
+	}
+	// pass signals on to the child and wait for it to finish
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sig)
+	go func() {
+		for s := range sig {
+			c.Process.Signal(s)
+		}
+	}()
+	err = c.Wait()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		if code := ee.ExitCode(); code >= 0 {
+			return exitStatus(code)
+		}
+		// killed, exit the way a shell does
+		if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return exitStatus(128 + int(ws.Signal()))
+		}
+	}
+	return err
+}
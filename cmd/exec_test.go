@@ -0,0 +1,62 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.2.3")
+	s.vers("set", "-f", "v.yaml", "web", "2.0.0")
+
+	tests := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{"environment", []string{"--", "sh", "-c", `printf %s "$API_VERS $WEB_VERS"`}, "v1.2.3 v2.0.0", exitOK},
+		{"components", []string{"--env-components", "--", "sh", "-c", `printf %s "$API_VERS_MAJOR.$API_VERS_MINOR.$API_VERS_PATCH"`}, "1.2.3", exitOK},
+		{"selected entries", []string{"-e", "web", "--", "sh", "-c", `printf %s "${API_VERS-unset} $WEB_VERS"`}, "unset v2.0.0", exitOK},
+		{"exit status", []string{"--", "sh", "-c", "exit 42"}, "", 42},
+		{"killed", []string{"--", "sh", "-c", "kill -TERM $$"}, "", 128 + 15},
+		{"without --", []string{"sh", "-c", `printf %s "$API_VERS"`}, "v1.2.3", exitOK},
+		// flags after the command are the command's
+		{"interspersed", []string{"sh", "-c", `printf "%s|" "$@"`, "x", "-e", "api", "--", "-f"}, "-e|api|--|-f|", exitOK},
+		{"no command", []string{"--"}, "", exitUsage},
+		{"unknown entry", []string{"-e", "db", "--", "true"}, "", exitNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, code := runVers(t, append([]string{"exec", "-f", "v.yaml"}, tt.args...)...)
+			if code != tt.code || out != tt.out {
+				t.Errorf("= %q, exit %d, want %q, exit %d", out, code, tt.out, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+)
+
+// Process exit codes, see the Exit Codes section of the help.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitNoHistory   = 4
+	exitLockTimeout = 5
+	exitBadFormat   = 6
+	exitConflict    = 7
+	exitPolicy      = 8
+	exitIO          = 9
+)
+
+// exitCodeHelp is the table shown in the root command help.
+const exitCodeHelp = `Exit Codes:
+  0  success
+  1  other failure
+  2  usage error (bad flags or arguments)
+  3  entry (or git tag) not found
+  4  entry has no previous value
+  5  lock on the version file not obtained
+  6  unsupported file type or output format
+  7  conflict (target already exists, entry has dependents or a group)
+  8  policy violation (invalid version, failed hook, dependency cycle,
+     diverged group)
+  9  I/O error`
+
+// codeError attaches an exit code to an error.
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// exitStatus passes a child's exit status through unchanged.
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// usageErrorf returns a usage error.
+func usageErrorf(format string, args ...interface{}) error {
+	return &codeError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// conflictErrorf returns an error for a target we won't overwrite.
+func conflictErrorf(format string, args ...interface{}) error {
+	return &codeError{code: exitConflict, err: fmt.Errorf(format, args...)}
+}
+
+// policyErrorf returns an error for a change the project policy
+// doesn't allow.
+func policyErrorf(format string, args ...interface{}) error {
+	return &codeError{code: exitPolicy, err: fmt.Errorf(format, args...)}
+}
+
+// processExit returns the code vers exits with after err, an error
+// before the command started running is a usage error.
+func processExit(err error) int {
+	code := exitCode(err)
+	if !started && code == exitFailure {
+		code = exitUsage
+	}
+	return code
+}
+
+// exitCode maps an error onto the process exit code.
+func exitCode(err error) int {
+	var ce *codeError
+	var es exitStatus
+	var pe *os.PathError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &es):
+		return int(es)
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, ventry.ErrEntryNotFound), errors.Is(err, vgit.ErrNoTag):
+		return exitNotFound
+	case errors.Is(err, ventry.ErrNoHistory):
+		return exitNoHistory
+	case errors.Is(err, ventry.ErrLockTimeout):
+		return exitLockTimeout
+	case errors.Is(err, ventry.ErrUnsupportedFormat):
+		return exitBadFormat
+	case errors.Is(err, ventry.ErrInvalidVersion), errors.Is(err, ventry.ErrCycle),
+		errors.Is(err, ventry.ErrDiverged):
+		return exitPolicy
+	case errors.Is(err, ventry.ErrHasDependents), errors.Is(err, ventry.ErrInGroup),
+		errors.Is(err, ventry.ErrExists):
+		return exitConflict
+	case errors.As(err, &pe):
+		return exitIO
+	}
+	return exitFailure
+}
@@ -0,0 +1,129 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofrs/flock"
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+)
+
+func TestExitCode(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("Bump failed on v.yaml; %w", &ventry.EntryError{Name: "api", Err: err})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"other", errors.New("boom"), exitFailure},
+		{"child status", exitStatus(42), 42},
+		{"usage", usageErrorf("bad %s", "flag"), exitUsage},
+		{"conflict", conflictErrorf("exists"), exitConflict},
+		{"policy", policyErrorf("hook failed"), exitPolicy},
+		{"wrapped code", fmt.Errorf("outer; %w", conflictErrorf("inner")), exitConflict},
+		{"not found", wrap(ventry.ErrEntryNotFound), exitNotFound},
+		{"no tag", fmt.Errorf("describe; %w", vgit.ErrNoTag), exitNotFound},
+		{"no history", wrap(ventry.ErrNoHistory), exitNoHistory},
+		{"lock timeout", fmt.Errorf("v.yaml; %w", ventry.ErrLockTimeout), exitLockTimeout},
+		{"bad format", fmt.Errorf("%q; %w", "xml", ventry.ErrUnsupportedFormat), exitBadFormat},
+		{"invalid version", wrap(ventry.ErrInvalidVersion), exitPolicy},
+		{"cycle", wrap(ventry.ErrCycle), exitPolicy},
+		{"diverged", wrap(ventry.ErrDiverged), exitPolicy},
+		{"has dependents", wrap(ventry.ErrHasDependents), exitConflict},
+		{"in group", wrap(ventry.ErrInGroup), exitConflict},
+		{"exists", wrap(ventry.ErrExists), exitConflict},
+		{"io", fmt.Errorf("Open failed; %w", &os.PathError{Op: "open", Path: "v.yaml", Err: os.ErrNotExist}), exitIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessExitBeforeStart(t *testing.T) {
+	defer func(s bool) { started = s }(started)
+	started = false
+	if got := processExit(errors.New(`unknown flag: --nope`)); got != exitUsage {
+		t.Errorf("got %d, want %d", got, exitUsage)
+	}
+	started = true
+	if got := processExit(errors.New("boom")); got != exitFailure {
+		t.Errorf("got %d, want %d", got, exitFailure)
+	}
+}
+
+func TestExitCodeCommands(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "-e", "api", "-M", "1", "-m", "0", "-p", "0")
+
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		want   int
+	}{
+		{"ok", "", []string{"get", "-f", "v.yaml", "-e", "api"}, exitOK},
+		{"unknown flag", "", []string{"get", "--nope"}, exitUsage},
+		{"bad bump level", "", []string{"bump", "-f", "v.yaml", "-e", "api", "-i", "huge"}, exitUsage},
+		{"bad package", "", []string{"gen", "go", "-f", "v.yaml", "--package", "my-pkg"}, exitUsage},
+		{"no version file", "", []string{"bump", "-e", "api", "-i", "minor"}, exitUsage},
+		{"not found", "", []string{"bump", "-f", "v.yaml", "-e", "web", "-i", "minor"}, exitNotFound},
+		{"no history", "", []string{"undo", "-f", "v.yaml", "-e", "api"}, exitNoHistory},
+		{"bad format", "", []string{"get", "-f", "v.yaml", "-e", "api", "-o", "xml"}, exitBadFormat},
+		{"exists", "", []string{"init", "-f", "v.yaml", "-e", "api"}, exitConflict},
+		{"failed hook", "hooks:\n  pre-bump: [\"false\"]\n", []string{"bump", "-f", "v.yaml", "-e", "api", "-i", "minor"}, exitPolicy},
+		{"invalid version", "scheme: semver\n", []string{"set", "-f", "v.yaml", "-e", "api", "-M", "-1", "-m", "0", "-p", "0"}, exitPolicy},
+		{"missing file", "", []string{"get", "-f", "gone.yaml"}, exitIO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.write(".vers.yaml", tt.config)
+			if _, got := runVers(t, tt.args...); got != tt.want {
+				t.Errorf("vers %v: exit %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("lock timeout", func(t *testing.T) {
+		s.write(".vers.yaml", "")
+		lck := flock.New(filepath.Join(s.dir, "v.yaml.lck"))
+		if err := lck.Lock(); err != nil {
+			t.Fatal(err)
+		}
+		defer lck.Unlock()
+		if _, got := runVers(t, "bump", "-f", "v.yaml", "-e", "api", "-i", "minor"); got != exitLockTimeout {
+			t.Errorf("exit %d, want %d", got, exitLockTimeout)
+		}
+	})
+}
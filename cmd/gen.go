@@ -0,0 +1,122 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// genCmd represents the gen command
+	genCmd = &cobra.Command{
+		Use:   "gen",
+		Short: "Generate source code from the version file",
+		Long:  "Generate source code from the version file",
+	}
+
+	// genGoCmd represents the gen go command
+	genGoCmd = &cobra.Command{
+		Use:   "go",
+		Short: "Generate a Go file with version constants",
+		Long: `Generate a gofmt'd Go file with constants for each entry, e.g. for
+the entry "web-ui":
+
+  WebUiVersion = "v1.2.3"
+  WebUiPrefix  = "v"
+  WebUiMajor   = 1
+  ...
+
+It is meant to be run from go generate:
+
+  //go:generate vers gen go -f ../versions.yaml --out version_gen.go
+
+The package defaults to $GOPACKAGE (set by go generate) or main.  With
+--check nothing is written and vers fails if the file is out of date.`,
+		Args:   cobra.NoArgs,
+		PreRun: bindFlags,
+		RunE:   genGo,
+	}
+)
+
+func init() {
+	genGoCmd.Flags().String(PKG, "", "package name (default $GOPACKAGE or main)")
+	genGoCmd.Flags().String(OUT, "", "file to write (default stdout)")
+	genGoCmd.Flags().Bool(CHECK, false, "fail if the file is not up to date instead of writing it")
+
+	genCmd.AddCommand(genGoCmd)
+	RootCmd.AddCommand(genCmd)
+}
+
+func genGo(cmd *cobra.Command, args []string) error {
+	pkg := viper.GetString(PKG)
+	if len(pkg) == 0 {
+		if pkg = os.Getenv("GOPACKAGE"); len(pkg) == 0 {
+			pkg = "main"
+		}
+	}
+	if !ventry.IsGoPackage(pkg) {
+		return usageErrorf("%q is not a Go package name (--%s)", pkg, PKG)
+	}
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	ents, err := vs.List()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	if ents, err = selectEntries(ents, viper.GetString(ENTRY)); err != nil {
+		return err
+	}
+	src, err := ventry.GoSource(pkg, filepath.Base(viper.GetString(VFILE)), ents)
+	if err != nil {
+		return err
+	}
+
+	out := viper.GetString(OUT)
+	if viper.GetBool(CHECK) {
+		if len(out) == 0 {
+			return usageErrorf("--%s needs --%s", CHECK, OUT)
+		}
+		cur, err := ioutil.ReadFile(out)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if !bytes.Equal(cur, src) {
+			return policyErrorf("%s is out of date, run vers gen go", out)
+		}
+		return nil
+	}
+	if len(out) == 0 {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
@@ -21,6 +21,10 @@ package cmd
 // THE SOFTWARE.
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/apex/log"
 	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
@@ -30,50 +34,60 @@ import (
 var (
 	// getCmd represents the get command
 	getCmd = &cobra.Command{
-		Use:   "get",
+		Use:   "get [entry]",
 		Short: "get version info",
-		Long:  `For the given binary get the current version information`,
-		Run:   get,
+		Long: `For the given binary get the current version information, the entry
+may be given as an argument or with --entry (all entries without either),
+"@group" gets the members of a group (see vers group)`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: get,
 	}
 )
 
 func init() {
-	getCmd.PersistentFlags().StringP(FMT, "o", "json", "Output format")
-	viper.BindPFlag(FMT, getCmd.PersistentFlags().Lookup(FMT))
-
 	RootCmd.AddCommand(getCmd)
 }
 
-func get(cmd *cobra.Command, args []string) {
-
-	if viper.GetBool(DEBUG) {
-		log.SetLevel(log.DebugLevel)
-	}
-
+func get(cmd *cobra.Command, args []string) error {
 	log.Debugf("filename: %s, fmt: %s, entry: %s",
 		viper.GetString(VFILE), viper.GetString(FMT),
 		viper.GetString(ENTRY))
 
-	filename := viper.GetString(VFILE)
-	if len(filename) == 0 {
-		log.Fatalf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	ents, err := vs.List()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
 	}
+	entry := entryArg(args)
+	if strings.HasPrefix(entry, ventry.GroupPrefix) {
+		if ents, err = groupEntries(vs, entry); err != nil {
+			return err
+		}
+		return renderer().RenderAll(os.Stdout, ents, outFmt("json"))
+	}
+	if len(entry) != 0 {
+		return renderer().Render(os.Stdout, ents, entry, outFmt("json"))
+	}
+	return renderer().RenderAll(os.Stdout, ents, outFmt("json"))
+}
 
-	vp, err := ventry.Open(filename, false)
+// groupEntries returns the members of a group.
+func groupEntries(vs ventry.Store, group string) (ventry.Entries, error) {
+	f, err := vs.Snapshot()
 	if err != nil {
-		log.Fatalf("Open failed on %s; %s", filename, err)
+		return nil, fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
 	}
-	defer vp.Close()
-	err = vp.Read(10)
+	members, err := f.Resolve(group)
 	if err != nil {
-		log.Fatalf("Read  failed on %s; %s", filename, err)
+		return nil, err
 	}
-	entry := viper.GetString(ENTRY)
-	if len(entry) != 0 {
-		if err := vp.Print(entry, viper.GetString(FMT)); err != nil {
-			log.Fatalf("Failed: %s", err)
-		}
-		return
+	ents := make(ventry.Entries)
+	for _, m := range members {
+		ents[m] = f.Version[m]
 	}
-	vp.Dump(viper.GetString(FMT))
+	return ents, nil
 }
@@ -0,0 +1,480 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rbg/vers/manifest"
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hookMarker marks the git hooks vers installed.
+const hookMarker = "# installed by vers hooks install"
+
+// gitHooks are the git hooks vers installs.
+var gitHooks = []string{"pre-commit", "commit-msg"}
+
+var (
+	// gitHooksCmd represents the hooks command
+	gitHooksCmd = &cobra.Command{
+		Use:   "hooks",
+		Short: "Git hooks checking commits keep the versions consistent",
+		Long: `Git hooks checking commits keep the versions consistent.
+
+pre-commit blocks a commit whose version file
+  - takes an entry's version backwards
+  - gives an entry a version its scheme does not allow
+  - deletes an entry
+and one that changes the version in a synced manifest or replace file
+(see vers sync and vers bump) without changing the entry's version.
+
+With commit-msg-version set in the project config, commit-msg blocks a
+commit changing an entry's version whose message does not give the new
+version (as vers bump --tag does).
+
+git commit --no-verify skips them.`,
+	}
+
+	// gitHooksInstallCmd represents the hooks install command
+	gitHooksInstallCmd = &cobra.Command{
+		Use:   "install",
+		Short: "Install the pre-commit and commit-msg hooks",
+		Long: `Write pre-commit and commit-msg hooks running vers hooks run into the
+repository's hooks directory, hooks that vers did not write are only
+replaced with --force.`,
+		Args:   cobra.NoArgs,
+		PreRun: bindFlags,
+		RunE:   gitHooksInstall,
+	}
+
+	// gitHooksUninstallCmd represents the hooks uninstall command
+	gitHooksUninstallCmd = &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the hooks vers installed",
+		Long:  "Remove the hooks vers installed, leaving any others alone.",
+		Args:  cobra.NoArgs,
+		RunE:  gitHooksUninstall,
+	}
+
+	// gitHooksRunCmd represents the hooks run command
+	gitHooksRunCmd = &cobra.Command{
+		Use:   "run <hook> [args...]",
+		Short: "Run a hook's checks (called by the installed hooks)",
+		Long:  "Run the checks of pre-commit or commit-msg, with the hook's arguments.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  gitHooksRun,
+	}
+)
+
+func init() {
+	gitHooksInstallCmd.Flags().Bool(FORCE, false, "replace hooks vers did not install")
+
+	gitHooksCmd.AddCommand(gitHooksInstallCmd)
+	gitHooksCmd.AddCommand(gitHooksUninstallCmd)
+	gitHooksCmd.AddCommand(gitHooksRunCmd)
+	RootCmd.AddCommand(gitHooksCmd)
+}
+
+// hookScript returns the script of a hook, the version file is passed
+// on if it was given on the command line or in the environment.  It
+// runs the vers installing it, as git GUIs and CI may not have it in
+// their PATH, or the one in the PATH if that is gone.
+func hookScript(r *vgit.Repo, hook string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	args := []string{`"$vers"`}
+	if explicit(VFILE) {
+		top, err := r.TopLevel()
+		if err != nil {
+			return "", err
+		}
+		p, err := filepath.Abs(viper.GetString(VFILE))
+		if err != nil {
+			return "", err
+		}
+		if rel, err := filepath.Rel(top, p); err == nil {
+			p = rel
+		}
+		args = append(args, "--"+VFILE, shellQuote(filepath.ToSlash(p)))
+	}
+	args = append(args, "hooks", "run", hook, `"$@"`)
+	return fmt.Sprintf("#!/bin/sh\n%s\nvers=%s\n[ -x \"$vers\" ] || vers=vers\nexec %s\n",
+		hookMarker, shellQuote(exe), strings.Join(args, " ")), nil
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ownHook reports whether the hook at path was installed by vers.
+func ownHook(path string) (exists, own bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, false
+	}
+	return true, bytes.Contains(data, []byte(hookMarker))
+}
+
+func gitHooksInstall(cmd *cobra.Command, args []string) error {
+	r, err := gitRepo()
+	if err != nil {
+		return err
+	}
+	dir, err := r.HooksDir()
+	if err != nil {
+		return err
+	}
+	for _, hook := range gitHooks {
+		p := filepath.Join(dir, hook)
+		if exists, own := ownHook(p); exists && !own && !viper.GetBool(FORCE) {
+			return conflictErrorf("%s; a hook exists already and --%s not set", p, FORCE)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, hook := range gitHooks {
+		script, err := hookScript(r, hook)
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(dir, hook)
+		if err := ioutil.WriteFile(p, []byte(script), 0755); err != nil {
+			return err
+		}
+		if err := os.Chmod(p, 0755); err != nil {
+			return err
+		}
+		fmt.Println(p)
+	}
+	return nil
+}
+
+func gitHooksUninstall(cmd *cobra.Command, args []string) error {
+	r, err := gitRepo()
+	if err != nil {
+		return err
+	}
+	dir, err := r.HooksDir()
+	if err != nil {
+		return err
+	}
+	for _, hook := range gitHooks {
+		p := filepath.Join(dir, hook)
+		switch exists, own := ownHook(p); {
+		case own:
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			fmt.Printf("removed %s\n", p)
+		case exists:
+			fmt.Printf("left %s, vers did not install it\n", p)
+		}
+	}
+	return nil
+}
+
+// staged is the commit being made.
+type staged struct {
+	r     *vgit.Repo
+	top   string
+	files map[string]bool
+	// vfile is the version file relative to top.
+	vfile string
+}
+
+func newStaged() (*staged, error) {
+	r, err := gitRepo()
+	if err != nil {
+		return nil, err
+	}
+	s := &staged{r: r, files: make(map[string]bool)}
+	if s.top, err = r.TopLevel(); err != nil {
+		return nil, err
+	}
+	if top, err := filepath.EvalSymlinks(s.top); err == nil {
+		s.top = top
+	}
+	files, err := r.Staged()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		s.files[f] = true
+	}
+	vfile := viper.GetString(VFILE)
+	if len(vfile) == 0 {
+		return nil, usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+	}
+	if s.vfile, err = s.rel(vfile); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// rel returns path relative to the top of the work tree.
+func (s *staged) rel(path string) (string, error) {
+	p, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// git gives top with symlinks resolved
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(p)); err == nil {
+		p = filepath.Join(dir, filepath.Base(p))
+	}
+	rel, err := filepath.Rel(s.top, p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// versions returns the version file at rev ("" the index), nil if it
+// is not there.
+func (s *staged) versions(rev string) (*ventry.VFile, error) {
+	data, ok, err := s.r.Show(rev, s.vfile)
+	if err != nil || !ok {
+		return nil, err
+	}
+	f, err := ventry.Decode(data, filepath.Ext(s.vfile))
+	if err != nil {
+		return nil, fmt.Errorf("%s; %w", s.vfile, err)
+	}
+	return f, nil
+}
+
+// changed returns the entries the commit gives a new version, with
+// their old and new version files.
+func (s *staged) changed() (names []string, old, cur *ventry.VFile, err error) {
+	if !s.files[s.vfile] {
+		return nil, nil, nil, nil
+	}
+	if old, err = s.versions("HEAD"); err != nil {
+		return nil, nil, nil, err
+	}
+	if cur, err = s.versions(""); err != nil {
+		return nil, nil, nil, err
+	}
+	if cur == nil {
+		return nil, old, nil, nil
+	}
+	for _, name := range cur.Version.Names() {
+		if old != nil {
+			if ov, ok := old.Version[name]; ok && ov.String() == cur.Version[name].String() {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	return names, old, cur, nil
+}
+
+// preCommit returns what is wrong with the commit.
+func (s *staged) preCommit() ([]string, error) {
+	var problems []string
+
+	names, old, cur, err := s.changed()
+	if err != nil {
+		return nil, err
+	}
+	if s.files[s.vfile] {
+		if cur == nil {
+			return []string{fmt.Sprintf("%s is deleted", s.vfile)}, nil
+		}
+		if old != nil {
+			for _, name := range old.Version.Names() {
+				nv, ok := cur.Version[name]
+				if !ok {
+					problems = append(problems, fmt.Sprintf("%s: entry deleted", name))
+					continue
+				}
+				if ov := old.Version[name]; ventry.Compare(*nv, *ov) < 0 {
+					problems = append(problems, fmt.Sprintf("%s: version goes backwards, %s to %s", name, ov, nv))
+				}
+			}
+		}
+		for _, name := range names {
+			if err := cur.Version[name].Check(entryString(name, SCHEME)); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+	}
+
+	// the manifests and replace files of the entries the commit does
+	// not give a new version must not change version either
+	head, err := s.versions("HEAD")
+	if err != nil || head == nil {
+		return problems, err
+	}
+	bumped := make(map[string]bool)
+	for _, name := range names {
+		bumped[name] = true
+	}
+	for _, name := range head.Version.Names() {
+		if bumped[name] {
+			continue
+		}
+		p, err := s.manifests(name, *head.Version[name])
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, p...)
+	}
+	return problems, nil
+}
+
+// manifests returns the entry's staged manifests and replace files
+// whose version changed.
+func (s *staged) manifests(name string, ve ventry.Vers) ([]string, error) {
+	var problems []string
+
+	es, err := entrySettings(name)
+	if err != nil {
+		return nil, err
+	}
+	show := func(path string) (before, after []byte, ok bool, err error) {
+		rel, err := s.rel(path)
+		if err != nil || !s.files[rel] {
+			return nil, nil, false, err
+		}
+		if before, ok, err = s.r.Show("HEAD", rel); err != nil || !ok {
+			return nil, nil, false, err
+		}
+		if after, ok, err = s.r.Show("", rel); err != nil || !ok {
+			return nil, nil, false, err
+		}
+		return before, after, true, nil
+	}
+	for _, sy := range es.Sync {
+		mt := manifest.Target{Path: projectPath(sy.File), Type: sy.Type, Key: sy.Key}
+		before, after, ok, err := show(mt.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		bv, berr := mt.ReadData(before)
+		av, aerr := mt.ReadData(after)
+		if berr == nil && (aerr != nil || av != bv) {
+			problems = append(problems, fmt.Sprintf("%s: %s changes version %s to %s, %s does not change %s", name, sy.File, bv, av, s.vfile, name))
+		}
+	}
+	for _, rs := range es.Replace {
+		rule, err := newReplaceRule(rs, name, ve, ve)
+		if err != nil {
+			return nil, err
+		}
+		files, err := filepath.Glob(projectPath(rule.Glob))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			before, after, ok, err := show(f)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || len(rule.check(before)) != 0 {
+				continue
+			}
+			if d := rule.check(after); len(d) != 0 {
+				problems = append(problems, fmt.Sprintf("%s: %s %s, %s does not change %s", name, relPath(f), d, s.vfile, name))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// commitMsg returns what is wrong with the message in file, nothing
+// unless commit-msg-version is set.
+func (s *staged) commitMsg(file string) ([]string, error) {
+	var problems []string
+
+	if !viper.GetBool(COMMITMSG) {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, l := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(l, "#") {
+			lines = append(lines, l)
+		}
+	}
+	msg := strings.Join(lines, "\n")
+	names, _, cur, err := s.changed()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		ve := cur.Version[name]
+		if !strings.Contains(msg, ve.String()) && !strings.Contains(msg, ve.Semver()) {
+			problems = append(problems, fmt.Sprintf("%s: the message does not give the new version %s", name, ve))
+		}
+	}
+	return problems, nil
+}
+
+func gitHooksRun(cmd *cobra.Command, args []string) error {
+	s, err := newStaged()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	switch args[0] {
+	case "pre-commit":
+		problems, err = s.preCommit()
+	case "commit-msg":
+		if len(args) < 2 {
+			return usageErrorf("commit-msg needs the message file")
+		}
+		problems, err = s.commitMsg(args[1])
+	default:
+		return usageErrorf("%q; vers has no such hook, one of %s", args[0], strings.Join(gitHooks, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "vers %s: %s\n", args[0], p)
+	}
+	if len(problems) != 0 {
+		return policyErrorf("commit blocked; %d problem(s), git commit --no-verify skips the check", len(problems))
+	}
+	return nil
+}
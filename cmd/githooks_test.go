@@ -0,0 +1,168 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hooksSandbox is a git sandbox holding the version file v.yaml (api
+// and web at 1.0.0) and their package.json manifests.
+func hooksSandbox(t *testing.T) *sandbox {
+	t.Helper()
+	s := gitSandbox(t)
+	if err := os.Mkdir(filepath.Join(s.dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s.write(".vers.yaml", `entries:
+  api:
+    sync:
+      - file: package.json
+  web:
+    sync:
+      - file: web/package.json
+`)
+	s.write("package.json", "{\"version\": \"1.0.0\"}\n")
+	s.write("web/package.json", "{\"version\": \"1.0.0\"}\n")
+	s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "web", "1.0.0")
+	s.commit("start")
+	return s
+}
+
+func TestHookScript(t *testing.T) {
+	s := hooksSandbox(t)
+	defer s.close()
+	s.vers("hooks", "install", "-f", "v.yaml")
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	for _, hook := range gitHooks {
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, ".git", "hooks", hook))
+		if err != nil {
+			t.Fatal(err)
+		}
+		script := string(data)
+		for _, want := range []string{hookMarker, "vers=" + shellQuote(exe), "hooks run " + hook} {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s hook does not have %q:\n%s", hook, want, script)
+			}
+		}
+	}
+}
+
+func TestPreCommit(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *sandbox)
+		want  int
+	}{
+		{"bump with its manifest", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0")
+			s.write("package.json", "{\"version\": \"1.1.0\"}\n")
+		}, exitOK},
+		{"manifest without a bump", func(s *sandbox) {
+			s.write("package.json", "{\"version\": \"1.1.0\"}\n")
+		}, exitPolicy},
+		{"manifest without a bump, version file staged", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0")
+			s.write("package.json", "{\"version\": \"1.1.0\"}\n")
+			s.write("web/package.json", "{\"version\": \"1.0.1\"}\n")
+		}, exitPolicy},
+		{"entry deleted", func(s *sandbox) {
+			s.vers("delete", "-f", "v.yaml", "web")
+		}, exitPolicy},
+		{"loose suffix", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0-01")
+			s.write("package.json", "{\"version\": \"1.1.0-01\"}\n")
+		}, exitOK},
+		{"malformed semver suffix", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0-01")
+			s.write("package.json", "{\"version\": \"1.1.0-01\"}\n")
+			// the scheme is tightened after the version was set
+			s.write(".vers.yaml", "entries:\n  api:\n    scheme: semver\n    sync:\n      - file: package.json\n")
+		}, exitPolicy},
+		{"other entry's manifest", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0")
+			s.write("package.json", "{\"version\": \"1.1.0\"}\n")
+			s.write("web/package.json", "{\"version\": \"2.0.0\"}\n")
+		}, exitPolicy},
+		{"backwards", func(s *sandbox) {
+			s.vers("set", "-f", "v.yaml", "api", "0.9.0")
+		}, exitPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := hooksSandbox(t)
+			defer s.close()
+			tt.setup(s)
+			s.git("add", "-A")
+			if _, code := runVers(t, "hooks", "run", "pre-commit", "-f", "v.yaml"); code != tt.want {
+				t.Errorf("exit %d, want %d", code, tt.want)
+			}
+		})
+	}
+
+	t.Run("no version file", func(t *testing.T) {
+		s := gitSandbox(t)
+		defer s.close()
+		s.write("package.json", "{\"version\": \"1.1.0\"}\n")
+		s.git("add", "-A")
+		if _, code := runVers(t, "hooks", "run", "pre-commit"); code != exitUsage {
+			t.Errorf("exit %d, want %d", code, exitUsage)
+		}
+	})
+}
+
+func TestCommitMsg(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		msg    string
+		want   int
+	}{
+		{"off by default", "", "release", exitOK},
+		{"version given", "commit-msg-version: true\n", "api 1.1.0", exitOK},
+		{"version missing", "commit-msg-version: true\n", "release", exitPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := hooksSandbox(t)
+			defer s.close()
+			s.write(".vers.yaml", tt.config)
+			s.vers("set", "-f", "v.yaml", "api", "1.1.0")
+			s.git("add", "v.yaml")
+			s.write("MSG", tt.msg+"\n")
+			if _, code := runVers(t, "hooks", "run", "commit-msg", "MSG", "-f", "v.yaml"); code != tt.want {
+				t.Errorf("exit %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
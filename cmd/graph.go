@@ -0,0 +1,119 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// graphCmd represents the graph command
+	graphCmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Show the dependencies between entries",
+		Long: `Show the dependencies between entries (see vers deps), every entry
+after those it depends on:
+
+  $ vers graph
+  ENTRY  VERSION  DEPENDS ON  DEPENDENTS
+  proto  v3.0.0   -           api, web
+  api    v1.2.0   proto       web
+  web    v2.0.0   api, proto  -
+
+-o dot writes it for Graphviz (vers graph -o dot | dot -Tsvg), json and
+yaml are supported too.`,
+		Args: cobra.NoArgs,
+		RunE: graph,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(graphCmd)
+}
+
+// graphNode is an entry in the graph.
+type graphNode struct {
+	Name       string
+	Version    string
+	DependsOn  []string
+	Dependents []string
+}
+
+func graph(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	vf, err := vs.Snapshot()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	order, err := vf.Order()
+	if err != nil {
+		return err
+	}
+	nodes := make([]graphNode, 0, len(order))
+	for _, n := range order {
+		nodes = append(nodes, graphNode{
+			Name:       n,
+			Version:    vf.Version[n].String(),
+			DependsOn:  vf.DependsOn(n),
+			Dependents: vf.Dependents(n),
+		})
+	}
+
+	return writeFormatted("text", nodes, map[string]func() error{
+		"text": func() error {
+			list := func(l []string) string {
+				if len(l) == 0 {
+					return "-"
+				}
+				return strings.Join(l, ", ")
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ENTRY\tVERSION\tDEPENDS ON\tDEPENDENTS")
+			for _, n := range nodes {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.Name, n.Version, list(n.DependsOn), list(n.Dependents))
+			}
+			return w.Flush()
+		},
+		"dot": func() error {
+			fmt.Println("digraph vers {")
+			for _, n := range nodes {
+				fmt.Printf("  %q [label=%q];\n", n.Name, n.Name+"\n"+n.Version)
+			}
+			for _, n := range nodes {
+				for _, d := range n.DependsOn {
+					fmt.Printf("  %q -> %q;\n", n.Name, d)
+				}
+			}
+			fmt.Println("}")
+			return nil
+		},
+	})
+}
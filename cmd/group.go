@@ -0,0 +1,131 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// groupCmd represents the group command
+	groupCmd = &cobra.Command{
+		Use:   "group",
+		Short: "Manage groups of entries versioned in lock-step",
+		Long: `Manage groups of entries versioned in lock-step, kept in the version
+file.  Setting, bumping or undoing a member (or "@group") changes every
+member at once, vers get @group shows them, and any change leaving the
+members on different versions is refused (exit 8).`,
+	}
+
+	// groupAddCmd represents the group add command
+	groupAddCmd = &cobra.Command{
+		Use:   "add <group> <entry>...",
+		Short: "Add entries to a group",
+		Long: `Add entries to a group, creating it if need be.  The entries must be
+on the same version as the group (exit 8) and in no other group (exit 7).
+
+  vers group add tools cli agent`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: groupAdd,
+	}
+
+	// groupRmCmd represents the group rm command
+	groupRmCmd = &cobra.Command{
+		Use:   "rm <group> [entry...]",
+		Short: "Remove entries from a group",
+		Long:  "Remove entries from a group, the whole group without any entries",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  groupRm,
+	}
+
+	// groupListCmd represents the group list command
+	groupListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the groups and their members",
+		Long:  "List the groups and their members",
+		Args:  cobra.NoArgs,
+		RunE:  groupList,
+	}
+)
+
+func init() {
+	groupCmd.AddCommand(groupAddCmd)
+	groupCmd.AddCommand(groupRmCmd)
+	groupCmd.AddCommand(groupListCmd)
+	RootCmd.AddCommand(groupCmd)
+}
+
+func groupAdd(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Group(args[0], args[1:]...)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", viper.GetString(VFILE), err)
+	}
+	return nil
+}
+
+func groupRm(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Ungroup(args[0], args[1:]...)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", viper.GetString(VFILE), err)
+	}
+	return nil
+}
+
+func groupList(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	f, err := vs.Snapshot()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	var names []string
+	for g := range f.Groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		fmt.Printf("%s%s %s\n", ventry.GroupPrefix, g, strings.Join(f.Groups[g], " "))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestGroupSetPrefix(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.write(".vers.yaml", "version-file: v.yaml\nentries:\n  cli:\n    prefix: cli-v\n")
+	s.vers("init", "agent", "1.0.0")
+	s.vers("set", "cli", "1.0.0")
+	s.vers("set", "lib", "2.0.0")
+	s.vers("group", "add", "tools", "cli", "agent")
+
+	check := func(want map[string]string) {
+		t.Helper()
+		for name, v := range want {
+			if got := s.vers("get", name, "-o", "str"); got != v+"\n" {
+				t.Errorf("%s is %q, want %q", name, got, v)
+			}
+		}
+	}
+	check(map[string]string{"cli": "cli-v1.0.0", "agent": "v1.0.0"})
+
+	s.vers("set", "@tools", "3.0.0")
+	check(map[string]string{"cli": "cli-v3.0.0", "agent": "v3.0.0"})
+
+	s.vers("set", "agent", "3.1.0")
+	check(map[string]string{"cli": "cli-v3.1.0", "agent": "v3.1.0"})
+
+	s.vers("bump", "cli", "patch")
+	check(map[string]string{"cli": "cli-v3.1.1", "agent": "v3.1.1"})
+
+	s.vers("set", "cli", "4.0.0", "--prefix", "r")
+	check(map[string]string{"cli": "r4.0.0", "agent": "r4.0.0"})
+
+	if _, code := runVers(t, "group", "add", "tools", "lib"); code != exitPolicy {
+		t.Errorf("adding a member on another version: exit %d, want %d", code, exitPolicy)
+	}
+	s.vers("set", "lib", "4.0.0")
+	s.vers("group", "add", "libs", "lib")
+	if _, code := runVers(t, "group", "add", "tools", "lib"); code != exitConflict {
+		t.Errorf("adding a member of another group: exit %d, want %d", code, exitConflict)
+	}
+}
@@ -26,7 +26,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/apex/log"
 	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -34,10 +33,12 @@ import (
 
 // initCmd represents the init command
 var initCmd = &cobra.Command{
-	Use:   "init",
+	Use:   "init [entry [version]]",
 	Short: "Make a new version file",
-	Long:  "Make a new version file",
-	Run:   newVers,
+	Long: `Make a new version file, the entry defaults to the file's base name
+and the version to v0.0.1`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: newVers,
 }
 
 func init() {
@@ -48,37 +49,43 @@ func init() {
 	RootCmd.AddCommand(initCmd)
 }
 
-func newVers(cmd *cobra.Command, args []string) {
-	if viper.GetBool(DEBUG) {
-		fmt.Println("Set Debug")
-		log.SetLevel(log.DebugLevel)
-	}
+func newVers(cmd *cobra.Command, args []string) error {
 	filename := viper.GetString(VFILE)
 	if len(filename) == 0 {
-		log.Fatalf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+		return usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
 	}
 
-	entry := viper.GetString(ENTRY)
+	entry := entryArg(args)
 	if len(entry) == 0 {
 		entry = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
 	}
 	if _, err := os.Stat(filename); err == nil {
 		if !viper.GetBool(FORCE) {
-			log.Fatalf("File exists already and --force not set")
+			return conflictErrorf("%s; File exists already and --force not set", filename)
 		}
 	}
-	vp, err := ventry.Open(filename, true)
+	ve, err := versionArg(entry, args, true)
+	if err != nil {
+		return err
+	}
+	if len(ve.Prefix) == 0 {
+		ve.Prefix = entryPrefix(entry)
+	}
+	if err := ve.Check(entryString(entry, SCHEME)); err != nil {
+		return fmt.Errorf("Invalid version for %s; %w", entry, err)
+	}
+	vs, err := openStore(true)
 	if err != nil {
-		log.Fatalf("Failed to open %s; %s", filename, err)
+		return err
 	}
-	defer vp.Close()
-	vp.Add(entry, &ventry.Vers{
-		Prefix: viper.GetString(PREFIX),
-		Major:  viper.GetInt(MAJ),
-		Minor:  viper.GetInt(MIN),
-		Patch:  viper.GetInt(PATCH),
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		f.Reset()
+		f.Set(entry, ve)
+		return nil
 	})
-	if err = vp.Write(3); err != nil {
-		log.Infof("Failed to write %s; %s", filename, err)
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", filename, err)
 	}
+	return nil
 }
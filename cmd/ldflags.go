@@ -0,0 +1,195 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// LdflagSettings maps a package variable to an entry or build value in
+// the project config, e.g.
+//
+//	ldflags:
+//	  - var: main.version
+//	    entry: api
+//	  - var: main.commit
+//	    value: commit
+type LdflagSettings struct {
+	// Var is the full package path of the variable (main.version).
+	Var string
+	// Entry is rendered through Format (default "{{.Version}}").
+	Entry  string
+	Format string
+	// Value is one of commit, short-commit or date, used instead of
+	// an entry.
+	Value string
+}
+
+var (
+	// ldflagsCmd represents the ldflags command
+	ldflagsCmd = &cobra.Command{
+		Use:   "ldflags",
+		Short: "Print -X flags setting package variables for go build",
+		Long: `Print -X flags setting package variables to versions for go build:
+
+  go build -ldflags "$(vers ldflags)"
+
+The mapping comes from the ldflags list in the project config and/or
+the command line:
+
+  vers ldflags --var main.version=api --commit main.commit --date main.date
+
+The date is the build time in UTC (RFC 3339), or $SOURCE_DATE_EPOCH
+when it is set for reproducible builds.`,
+		Args:   cobra.NoArgs,
+		PreRun: bindFlags,
+		RunE:   ldflags,
+	}
+)
+
+func init() {
+	ldflagsCmd.Flags().StringSlice(VAR, nil, "package variable and entry (main.version=api)")
+	ldflagsCmd.Flags().String(COMMIT, "", "package variable for the git commit hash")
+	ldflagsCmd.Flags().String(DATE, "", "package variable for the build date")
+
+	RootCmd.AddCommand(ldflagsCmd)
+}
+
+// buildValue returns the value of a build time setting.
+func buildValue(what string) (string, error) {
+	switch what {
+	case "commit", "short-commit":
+		r, err := vgit.Open("")
+		if err != nil {
+			return "", err
+		}
+		if what == "commit" {
+			return r.Head()
+		}
+		return r.ShortHead()
+	case "date":
+		t := time.Now()
+		if s := os.Getenv("SOURCE_DATE_EPOCH"); len(s) != 0 {
+			sec, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("SOURCE_DATE_EPOCH; %s", err)
+			}
+			t = time.Unix(sec, 0)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("%q; ldflags value must be one of commit, short-commit or date", what)
+}
+
+// ldflagsQuote quotes a -X argument the way go build splits -ldflags:
+// in single or double quotes with no escapes, so s can not have both.
+func ldflagsQuote(s string) (string, error) {
+	switch {
+	case !strings.ContainsAny(s, " \t\n'\""):
+		return s, nil
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'", nil
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`, nil
+	}
+	return "", fmt.Errorf("%s; go build can not be given both ' and \" in one -X value", s)
+}
+
+func ldflags(cmd *cobra.Command, args []string) error {
+	var vars []LdflagSettings
+	if err := viper.UnmarshalKey(LDFLAGS, &vars); err != nil {
+		return fmt.Errorf("%s; %s", LDFLAGS, err)
+	}
+	for _, v := range viper.GetStringSlice(VAR) {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 || len(kv[1]) == 0 {
+			return usageErrorf("--%s %q; should be package.variable=entry", VAR, v)
+		}
+		vars = append(vars, LdflagSettings{Var: kv[0], Entry: kv[1]})
+	}
+	if c := viper.GetString(COMMIT); len(c) != 0 {
+		vars = append(vars, LdflagSettings{Var: c, Value: "commit"})
+	}
+	if d := viper.GetString(DATE); len(d) != 0 {
+		vars = append(vars, LdflagSettings{Var: d, Value: "date"})
+	}
+	if len(vars) == 0 {
+		return usageErrorf("no variables; use --%s or the %s list in the config", VAR, LDFLAGS)
+	}
+
+	var ents ventry.Entries
+	var flags []string
+	for _, v := range vars {
+		var val string
+		switch {
+		case len(v.Var) == 0:
+			return fmt.Errorf("%s; every item needs a var", LDFLAGS)
+		case len(v.Entry) != 0:
+			if ents == nil {
+				vs, err := openStore(false)
+				if err != nil {
+					return err
+				}
+				ents, err = vs.List()
+				vs.Close()
+				if err != nil {
+					return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+				}
+			}
+			ve, ok := ents[v.Entry]
+			if !ok {
+				return &ventry.EntryError{Name: v.Entry, Err: ventry.ErrEntryNotFound}
+			}
+			f := v.Format
+			if len(f) == 0 {
+				f = "{{.Version}}"
+			}
+			t, err := ventry.NewTemplate(v.Var, f)
+			if err != nil {
+				return err
+			}
+			if val, err = ventry.ExecTemplate(t, v.Entry, *ve); err != nil {
+				return err
+			}
+		default:
+			var err error
+			if val, err = buildValue(v.Value); err != nil {
+				return err
+			}
+		}
+		q, err := ldflagsQuote(v.Var + "=" + val)
+		if err != nil {
+			return err
+		}
+		flags = append(flags, "-X "+q)
+	}
+	fmt.Println(strings.Join(flags, " "))
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+)
+
+// splitLdflags splits -ldflags as go build does (cmd/internal/quoted):
+// on spaces, with single or double quoted fields and no escapes.
+func splitLdflags(s string) []string {
+	var out []string
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t\n\r")
+		if len(s) == 0 {
+			break
+		}
+		if q := s[0]; q == '\'' || q == '"' {
+			i := strings.IndexByte(s[1:], q)
+			if i < 0 {
+				return append(out, "<unterminated>")
+			}
+			out = append(out, s[1:i+1])
+			s = s[i+2:]
+			continue
+		}
+		i := strings.IndexAny(s, " \t\n\r")
+		if i < 0 {
+			i = len(s)
+		}
+		out = append(out, s[:i])
+		s = s[i:]
+	}
+	return out
+}
+
+func TestLdflagsQuote(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+		err bool
+	}{
+		{"main.version=v1.2.0", "main.version=v1.2.0", false},
+		{"main.name=my app", "'main.name=my app'", false},
+		{"main.name=it's", `"main.name=it's"`, false},
+		{"main.name=it's mine", `"main.name=it's mine"`, false},
+		{`main.name=say "hi"`, `'main.name=say "hi"'`, false},
+		{`main.name=it's "hi"`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ldflagsQuote(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("ldflagsQuote(%q) error %v, want error %v", tt.in, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.out {
+				t.Errorf("ldflagsQuote(%q) = %q, want %q", tt.in, got, tt.out)
+			}
+			if f := splitLdflags("-X " + got); len(f) != 2 || f[1] != tt.in {
+				t.Errorf("go build would split %q into %q", got, f)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// listCmd represents the list command
+	listCmd = &cobra.Command{
+		Use:   "list [glob...]",
+		Short: "List the entries in the version file",
+		Long: `List the entries in the version file as a table (name, version, scheme,
+last changed and by whom), or as json, yaml or csv with -o.
+
+Entries can be filtered by name with glob arguments (or --match) and/or
+a --regex, and sorted by name, version or date.`,
+		RunE: list,
+	}
+)
+
+// listRow is a single line of list output.
+type listRow struct {
+	Name    string
+	Version string
+	Scheme  string
+	Changed *time.Time `json:",omitempty" yaml:",omitempty"`
+	By      string     `json:",omitempty" yaml:",omitempty"`
+
+	vers ventry.Vers
+}
+
+func init() {
+	listCmd.Flags().StringSlice(MATCH, nil, "only list entries whose name matches one of these globs")
+	viper.BindPFlag(MATCH, listCmd.Flags().Lookup(MATCH))
+
+	listCmd.Flags().String(REGEX, "", "only list entries whose name matches this regular expression")
+	viper.BindPFlag(REGEX, listCmd.Flags().Lookup(REGEX))
+
+	listCmd.Flags().String(SORT, "name", "sort by one of 'name, version or date'")
+	viper.BindPFlag(SORT, listCmd.Flags().Lookup(SORT))
+
+	listCmd.Flags().Bool(REVERSE, false, "reverse the sort order")
+	viper.BindPFlag(REVERSE, listCmd.Flags().Lookup(REVERSE))
+
+	RootCmd.AddCommand(listCmd)
+}
+
+// listFilter returns a test for entry names from the globs and regex.
+func listFilter(globs []string, expr string) (func(string) bool, error) {
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, usageErrorf("%q; %s", g, err)
+		}
+	}
+	var re *regexp.Regexp
+	if len(expr) != 0 {
+		var err error
+		if re, err = regexp.Compile(expr); err != nil {
+			return nil, usageErrorf("--%s %q; %s", REGEX, expr, err)
+		}
+	}
+	return func(name string) bool {
+		if re != nil && !re.MatchString(name) {
+			return false
+		}
+		if len(globs) == 0 {
+			return true
+		}
+		for _, g := range globs {
+			if ok, _ := path.Match(g, name); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// sortRows orders the rows by name, version or date (oldest first).
+func sortRows(rows []listRow, by string, reverse bool) error {
+	var less func(a, b listRow) bool
+	switch by {
+	case "name":
+		less = func(a, b listRow) bool { return a.Name < b.Name }
+	case "version":
+		less = func(a, b listRow) bool {
+			if c := ventry.Compare(a.vers, b.vers); c != 0 {
+				return c < 0
+			}
+			return a.Name < b.Name
+		}
+	case "date":
+		less = func(a, b listRow) bool {
+			switch {
+			case a.Changed == nil && b.Changed == nil:
+				return a.Name < b.Name
+			case a.Changed == nil:
+				return true
+			case b.Changed == nil:
+				return false
+			case a.Changed.Equal(*b.Changed):
+				return a.Name < b.Name
+			}
+			return a.Changed.Before(*b.Changed)
+		}
+	default:
+		return usageErrorf("valid values for --%s are one of the following: `name,version,date`", SORT)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if reverse {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return nil
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	globs := append(append([]string(nil), args...), viper.GetStringSlice(MATCH)...)
+	match, err := listFilter(globs, viper.GetString(REGEX))
+	if err != nil {
+		return err
+	}
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	ents, err := vs.List()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+
+	rows := []listRow{}
+	for name, ve := range ents {
+		if !match(name) {
+			continue
+		}
+		rows = append(rows, listRow{
+			Name:    name,
+			Version: ve.String(),
+			Scheme:  entryString(name, SCHEME),
+			Changed: ve.Changed,
+			By:      ve.By,
+			vers:    *ve,
+		})
+	}
+	if err := sortRows(rows, viper.GetString(SORT), viper.GetBool(REVERSE)); err != nil {
+		return err
+	}
+
+	return writeFormatted("table", rows, map[string]func() error{
+		"table": func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVERSION\tSCHEME\tCHANGED\tBY")
+			for _, r := range rows {
+				changed := "-"
+				if r.Changed != nil {
+					changed = r.Changed.Local().Format("2006-01-02 15:04:05")
+				}
+				by := r.By
+				if len(by) == 0 {
+					by = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Name, r.Version, r.Scheme, changed, by)
+			}
+			return w.Flush()
+		},
+		"csv": func() error {
+			w := csv.NewWriter(os.Stdout)
+			w.Write([]string{"name", "version", "scheme", "changed", "by"})
+			for _, r := range rows {
+				var changed string
+				if r.Changed != nil {
+					changed = r.Changed.Format(time.RFC3339)
+				}
+				w.Write([]string{r.Name, r.Version, r.Scheme, changed, r.By})
+			}
+			w.Flush()
+			return w.Error()
+		},
+	})
+}
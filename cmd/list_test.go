@@ -0,0 +1,128 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbg/vers/ventry"
+)
+
+func TestListFilter(t *testing.T) {
+	names := []string{"api", "api-v2", "svc-auth", "svc-billing", "web"}
+	tests := []struct {
+		name  string
+		globs []string
+		regex string
+		want  []string
+		err   bool
+	}{
+		{name: "all", want: names},
+		{name: "glob", globs: []string{"svc-*"}, want: []string{"svc-auth", "svc-billing"}},
+		{name: "globs", globs: []string{"api", "w?b"}, want: []string{"api", "web"}},
+		{name: "regex", regex: `^api(-v\d+)?$`, want: []string{"api", "api-v2"}},
+		{name: "regex is not anchored", regex: "b", want: []string{"svc-billing", "web"}},
+		{name: "both", globs: []string{"svc-*", "web"}, regex: "i", want: []string{"svc-billing"}},
+		{name: "none", globs: []string{"db"}, want: nil},
+		{name: "bad glob", globs: []string{"[a"}, err: true},
+		{name: "bad regex", regex: "(", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := listFilter(tt.globs, tt.regex)
+			if tt.err {
+				if exitCode(err) != exitUsage {
+					t.Errorf("error %v, want a usage error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, n := range names {
+				if match(n) {
+					got = append(got, n)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortRows(t *testing.T) {
+	at := func(s string) *time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &tm
+	}
+	row := func(name, version string, changed *time.Time) listRow {
+		ve, err := ventry.Parse(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return listRow{Name: name, Version: version, Changed: changed, vers: ve}
+	}
+	rows := []listRow{
+		row("web", "v1.10.0", at("2020-03-01T00:00:00Z")),
+		row("api", "v1.9.0", at("2020-01-01T00:00:00Z")),
+		row("cli", "v1.9.0", nil),
+		row("db", "2.0.0-rc.1", at("2020-03-01T00:00:00Z")),
+		row("auth", "2.0.0", nil),
+	}
+	tests := []struct {
+		by      string
+		reverse bool
+		want    []string
+	}{
+		{"name", false, []string{"api", "auth", "cli", "db", "web"}},
+		{"name", true, []string{"web", "db", "cli", "auth", "api"}},
+		// numbers compare as numbers, a pre-release before its release,
+		// ties by name
+		{"version", false, []string{"api", "cli", "web", "db", "auth"}},
+		{"version", true, []string{"auth", "db", "web", "cli", "api"}},
+		// never changed first, ties by name
+		{"date", false, []string{"auth", "cli", "api", "db", "web"}},
+		{"date", true, []string{"web", "db", "api", "cli", "auth"}},
+	}
+	for _, tt := range tests {
+		r := append([]listRow(nil), rows...)
+		if err := sortRows(r, tt.by, tt.reverse); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, row := range r {
+			got = append(got, row.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s reverse=%v: %v, want %v", tt.by, tt.reverse, got, tt.want)
+		}
+	}
+	if err := sortRows(rows, "size", false); exitCode(err) != exitUsage {
+		t.Errorf("sort by size: %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// renameCmd represents the rename command
+	renameCmd = &cobra.Command{
+		Use:   "rename <entry> <new-name>",
+		Short: "Rename an entry keeping its history",
+		Long: `Rename an entry keeping its history (for vers undo), dependencies and
+group, the entries depending on it depend on the new name.  An existing
+entry is only replaced with --force (exit 7 without).
+
+Settings for the entry in the project config are not renamed.`,
+		Args:   entryPairArgs,
+		PreRun: bindFlags,
+		RunE:   rename,
+	}
+)
+
+func init() {
+	renameCmd.Flags().Bool(FORCE, false, "replace the new entry if it exists")
+
+	RootCmd.AddCommand(renameCmd)
+}
+
+// entryPairArgs checks the source and target entry arguments of rename
+// and copy.
+func entryPairArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+		return usageErrorf("%s", err)
+	}
+	if len(viper.GetString(VFILE)) == 0 {
+		return usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+	}
+	if len(args[1]) == 0 || strings.HasPrefix(args[1], ventry.GroupPrefix) {
+		return usageErrorf("%q; entry names can not be empty or start with %q", args[1], ventry.GroupPrefix)
+	}
+	return nil
+}
+
+// warnSettings points out project settings left behind under the old
+// name.
+func warnSettings(src, dst string) {
+	if viper.IsSet(ENTRIES+"."+src) && !viper.IsSet(ENTRIES+"."+dst) {
+		log.Warnf("%s.%s in the project config does not apply to %s", ENTRIES, src, dst)
+	}
+}
+
+func rename(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	defer vs.Close()
+	err = vs.Update(func(f *ventry.VFile) error {
+		return f.Rename(args[0], args[1], viper.GetBool(FORCE))
+	})
+	if err != nil {
+		return fmt.Errorf("rename of %s failed on %s; %w", args[0], viper.GetString(VFILE), err)
+	}
+	warnSettings(args[0], args[1])
+	return nil
+}
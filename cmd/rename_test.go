@@ -0,0 +1,55 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameCopyExists(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "web", "2.0.0")
+
+	for _, cmd := range []string{"rename", "copy"} {
+		if _, code := runVers(t, cmd, "-f", "v.yaml", "api", "web"); code != exitConflict {
+			t.Errorf("%s onto web: exit %d, want %d", cmd, code, exitConflict)
+		}
+		if out := s.vers("get", "-f", "v.yaml", "web", "-o", "str"); strings.TrimSpace(out) != "v2.0.0" {
+			t.Errorf("after %s web is %q, want v2.0.0", cmd, out)
+		}
+	}
+
+	s.vers("copy", "-f", "v.yaml", "--force", "api", "web")
+	if out := s.vers("get", "-f", "v.yaml", "web", "-o", "str"); strings.TrimSpace(out) != "v1.0.0" {
+		t.Errorf("after copy --force web is %q, want v1.0.0", out)
+	}
+	s.vers("set", "-f", "v.yaml", "web", "3.0.0")
+	s.vers("rename", "-f", "v.yaml", "--force", "api", "web")
+	if out := s.vers("get", "-f", "v.yaml", "web", "-o", "str"); strings.TrimSpace(out) != "v1.0.0" {
+		t.Errorf("after rename --force web is %q, want v1.0.0", out)
+	}
+	if _, code := runVers(t, "get", "-f", "v.yaml", "api"); code != exitNotFound {
+		t.Errorf("get api after rename: exit %d, want %d", code, exitNotFound)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/rbg/vers/ventry"
+)
+
+// replaceData is what the search and replace templates see.
+type replaceData struct {
+	Name       string
+	Old        string
+	New        string
+	OldVersion string
+	NewVersion string
+}
+
+// replaceRule is a compiled search and replace rule.
+type replaceRule struct {
+	ReplaceSettings
+	text    string
+	search  *regexp.Regexp
+	replace []byte
+}
+
+// newReplaceRule renders the templates of s for a change of entry
+// name from old to nv.
+func newReplaceRule(s ReplaceSettings, name string, old, nv ventry.Vers) (*replaceRule, error) {
+	if len(s.Glob) == 0 {
+		return nil, fmt.Errorf("%s.%s.replace; every item needs a glob", ENTRIES, name)
+	}
+	search, replace := s.Search, s.Replace
+	if len(search) == 0 {
+		search = "{{.Old}}"
+	}
+	if len(replace) == 0 {
+		replace = "{{.New}}"
+	}
+	d := replaceData{
+		Name:       name,
+		Old:        old.Semver(),
+		New:        nv.Semver(),
+		OldVersion: old.String(),
+		NewVersion: nv.String(),
+	}
+	r := &replaceRule{ReplaceSettings: s}
+	rd := d
+	if s.Regex {
+		rd.Old, rd.New = regexp.QuoteMeta(d.Old), regexp.QuoteMeta(d.New)
+		rd.OldVersion, rd.NewVersion = regexp.QuoteMeta(d.OldVersion), regexp.QuoteMeta(d.NewVersion)
+	}
+	text, err := execReplace(s.Glob, search, rd)
+	if err != nil {
+		return nil, err
+	}
+	r.text = text
+	if !s.Regex {
+		text = regexp.QuoteMeta(text)
+	}
+	if r.search, err = regexp.Compile("(?m)" + text); err != nil {
+		return nil, fmt.Errorf("%s; %w", s.Glob, err)
+	}
+	text, err = execReplace(s.Glob, replace, d)
+	if err != nil {
+		return nil, err
+	}
+	r.replace = []byte(text)
+	return r, nil
+}
+
+func execReplace(name, text string, d replaceData) (string, error) {
+	var buf bytes.Buffer
+
+	t, err := ventry.NewTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// files returns the files the rule applies to.
+func (r *replaceRule) files() ([]string, error) {
+	files, err := filepath.Glob(projectPath(r.Glob))
+	if err != nil {
+		return nil, fmt.Errorf("%s; %w", r.Glob, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s; matches no files", r.Glob)
+	}
+	return files, nil
+}
+
+// check returns how data disagrees with a rule made for a single
+// version (old and new the same), "" if it does not: the search is not
+// found or the replacement would change what it finds, as it does
+// for a version-agnostic regex.
+func (r *replaceRule) check(data []byte) string {
+	matches := r.search.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return fmt.Sprintf("does not have %q", r.text)
+	}
+	for _, m := range matches {
+		found, want := data[m[0]:m[1]], r.replace
+		if r.Regex {
+			want = r.search.Expand(nil, r.replace, data, m)
+		}
+		if !bytes.Equal(found, want) {
+			return fmt.Sprintf("has %q, want %q", found, want)
+		}
+	}
+	return ""
+}
+
+// apply returns data with every match replaced, it is an error for
+// there to be none.
+func (r *replaceRule) apply(path string, data []byte) ([]byte, error) {
+	if !r.search.Match(data) {
+		return nil, fmt.Errorf("%s; %q not found", relPath(path), r.text)
+	}
+	if r.Regex {
+		return r.search.ReplaceAll(data, r.replace), nil
+	}
+	return r.search.ReplaceAllLiteral(data, r.replace), nil
+}
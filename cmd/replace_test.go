@@ -0,0 +1,92 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/rbg/vers/ventry"
+)
+
+func TestReplaceRuleCheck(t *testing.T) {
+	ve := ventry.Vers{Prefix: "v", Major: 1, Minor: 2}
+	define := ReplaceSettings{
+		Glob:    "api.h",
+		Search:  `#define API_VERSION "[^"]*"`,
+		Replace: `#define API_VERSION "{{.New}}"`,
+		Regex:   true,
+	}
+	tests := []struct {
+		name  string
+		rule  ReplaceSettings
+		data  string
+		drift bool
+	}{
+		{"literal found", ReplaceSettings{Glob: "README.md"}, "version 1.2.0\n", false},
+		{"literal missing", ReplaceSettings{Glob: "README.md"}, "version 0.9.0\n", true},
+		{"badge found", ReplaceSettings{Glob: "README.md", Search: "badge/version-{{.Old}}-", Replace: "badge/version-{{.New}}-"},
+			"![v](badge/version-1.2.0-blue)\n", false},
+		{"regex current", define, "#define API_VERSION \"1.2.0\"\n", false},
+		{"regex stale", define, "#define API_VERSION \"0.9.0\"\n", true},
+		{"regex one of two stale", define, "#define API_VERSION \"1.2.0\"\n#define API_VERSION \"0.9.0\"\n", true},
+		{"regex missing", define, "int main() {}\n", true},
+		{"regex groups", ReplaceSettings{Glob: "x", Search: `(version: )\S+`, Replace: "${1}{{.NewVersion}}", Regex: true},
+			"version: v1.2.0\n", false},
+		{"regex groups stale", ReplaceSettings{Glob: "x", Search: `(version: )\S+`, Replace: "${1}{{.NewVersion}}", Regex: true},
+			"version: v1.1.0\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newReplaceRule(tt.rule, "api", ve, ve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := r.check([]byte(tt.data)); (len(d) != 0) != tt.drift {
+				t.Errorf("check(%q) = %q, want drift %v", tt.data, d, tt.drift)
+			}
+		})
+	}
+}
+
+func TestSyncCheckRegex(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+	s.write(".vers.yaml", `entries:
+  api:
+    replace:
+      - glob: api.h
+        search: '#define API_VERSION "[^"]*"'
+        replace: '#define API_VERSION "{{.New}}"'
+        regex: true
+`)
+	s.write("api.h", "#define API_VERSION \"0.9.0\"\n")
+	if _, code := runVers(t, "sync", "-f", "v.yaml", "--check"); code != exitPolicy {
+		t.Errorf("sync --check with api.h at 0.9.0: exit %d, want %d", code, exitPolicy)
+	}
+	if _, code := runVers(t, "verify", "-f", "v.yaml"); code != exitPolicy {
+		t.Errorf("verify with api.h at 0.9.0: exit %d, want %d", code, exitPolicy)
+	}
+	s.write("api.h", "#define API_VERSION \"1.2.0\"\n")
+	if _, code := runVers(t, "sync", "-f", "v.yaml", "--check"); code != exitOK {
+		t.Errorf("sync --check with api.h at 1.2.0: exit %d, want %d", code, exitOK)
+	}
+}
@@ -21,45 +21,106 @@ package cmd
 // THE SOFTWARE.
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/apex/log"
+	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 const (
-	BUMP   = "bump"
-	CFG    = "config"
-	DEBUG  = "debug"
-	ENTRY  = "entry"
-	FMT    = "fmt"
-	FORCE  = "force"
-	MAJ    = "major"
-	MIN    = "minor"
-	PATCH  = "patch"
-	PREFIX = "prefix"
-	SUFFIX = "suffix"
-	VFILE  = "version-file"
+	AUTO      = "auto"
+	AUTOSYNC  = "auto-sync"
+	BUMP      = "bump"
+	CASCADE   = "cascade"
+	CFG       = "config"
+	CHANGED   = "changed"
+	CHANGES   = "changelog"
+	CHECK     = "check"
+	COMMIT    = "commit"
+	COMMITMSG = "commit-msg-version"
+	CONVCOMM  = "conventional"
+	DATE      = "date"
+	DEBUG     = "debug"
+	ENTRIES   = "entries"
+	ENTRY     = "entry"
+	ENVCOMP   = "env-components"
+	ENVNAME   = "env-name"
+	FMT       = "fmt"
+	FORCE     = "force"
+	FROM      = "from"
+	HISTORY   = "history"
+	HOOKS     = "hooks"
+	LDFLAGS   = "ldflags"
+	MAJ       = "major"
+	MATCH     = "match"
+	MIN       = "minor"
+	NOCASCADE = "no-cascade"
+	OUT       = "out"
+	PATCH     = "patch"
+	PKG       = "package"
+	PREFIX    = "prefix"
+	REGEX     = "regex"
+	REVERSE   = "reverse"
+	SCHEME    = "scheme"
+	SIGN      = "sign"
+	SINCE     = "since"
+	SORT      = "sort"
+	SUFFIX    = "suffix"
+	SYNC      = "sync"
+	TAG       = "tag"
+	TAGMSG    = "tag-message"
+	TAGTMPL   = "tag-template"
+	TO        = "to"
+	VAR       = "var"
+	VFILE     = "version-file"
+	WRITE     = "write"
+	WRITECL   = "write-changelog"
 )
 
-var cfgFile string
-
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "vers",
 	Short: "A simple way to manage versions",
-	Long:  `Handle versions....`,
+	Long:  "Handle versions....\n\n" + exitCodeHelp,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		started = true
+		if viper.GetBool(DEBUG) {
+			log.SetLevel(log.DebugLevel)
+		}
+		return cfgErr
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
+var (
+	// started is set once the command line has been parsed and a
+	// command is running, errors before that are usage errors.
+	started bool
+	// cfgErr is any error reading the config files.
+	cfgErr error
+)
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	err := RootCmd.Execute()
+	if err == nil {
+		return
+	}
+	var es exitStatus
+	if errors.As(err, &es) {
+		os.Exit(int(es))
 	}
+	log.Error(err.Error())
+	os.Exit(processExit(err))
 }
 
 func init() {
@@ -92,26 +153,168 @@ func init() {
 	RootCmd.PersistentFlags().StringP(ENTRY, "e", "", "Which entry in version file")
 	viper.BindPFlag(ENTRY, RootCmd.PersistentFlags().Lookup(ENTRY))
 
+	RootCmd.PersistentFlags().StringP(FMT, "o", "", "Output format (default from config, else json)")
+	viper.BindPFlag(FMT, RootCmd.PersistentFlags().Lookup(FMT))
+
+	RootCmd.PersistentFlags().String(ENVNAME, ventry.DefaultEnvName, "template for environment variable names")
+	viper.BindPFlag(ENVNAME, RootCmd.PersistentFlags().Lookup(ENVNAME))
+
+	RootCmd.PersistentFlags().Bool(ENVCOMP, false, "also export _MAJOR, _MINOR and _PATCH variables")
+	viper.BindPFlag(ENVCOMP, RootCmd.PersistentFlags().Lookup(ENVCOMP))
+
+	viper.SetDefault(AUTOSYNC, false)
+	viper.SetDefault(COMMITMSG, false)
+	viper.SetDefault(HISTORY, 1)
+	viper.SetDefault(SCHEME, "loose")
+	viper.SetDefault(WRITECL, false)
 }
 
-// initConfig reads in config file and ENV variables if set.
+// initConfig reads in config files and ENV variables if set.  The
+// config files are layered, each one overriding the one before it:
+// /etc/h9k, then $HOME, then the project (the nearest .vers file
+// in the current directory or its parents, or --config).
 func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		viper.AddConfigPath("/etc/h9k")
-		viper.AddConfigPath("$HOME")
-		viper.AddConfigPath(".")
-		viper.SetConfigName(".vers")
+	// read in VERS_ prefixed environment variables (VERS_VERSION_FILE)
+	viper.SetEnvPrefix("VERS")
+	viper.SetEnvKeyReplacer(envReplacer)
+	viper.AutomaticEnv()
+	bindEnv(RootCmd)
+
+	layers, project := configLayers(viper.GetString(CFG))
+	if len(project) != 0 {
+		if abs, err := filepath.Abs(project); err == nil {
+			projectDir = filepath.Dir(abs)
+		}
+	}
+	for _, p := range layers {
+		if err := mergeConfigLayer(p); err != nil {
+			cfgErr = fmt.Errorf("Config file was found but an error occured; %w", err)
+			return
+		}
+	}
+}
+
+// bindFlags binds the local flags of cmd to viper when it runs, for
+// flags that share a name with those of other commands.
+func bindFlags(cmd *cobra.Command, args []string) {
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Name != "help" {
+			viper.BindPFlag(f.Name, f)
+		}
+	})
+}
+
+// envReplacer maps config keys onto environment variable names.
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// envKey is the environment variable viper consults for key.
+func envKey(key string) string {
+	return "VERS_" + strings.ToUpper(envReplacer.Replace(key))
+}
+
+// envSettings are the config settings, other than the global flags,
+// that can be given as VERS_ environment variables.
+var envSettings = []string{AUTOSYNC, COMMITMSG, HISTORY, SCHEME, TAGMSG, TAGTMPL, WRITECL}
+
+// bindEnv explicitly maps the global flags of c and the envSettings to
+// their VERS_ environment variables; the flags of single commands are
+// still read from them, but are not settings.
+func bindEnv(c *cobra.Command) {
+	c.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		viper.BindEnv(f.Name, envKey(f.Name))
+	})
+	for _, k := range envSettings {
+		viper.BindEnv(k, envKey(k))
 	}
+}
 
-	viper.AutomaticEnv() // read in environment variables that match
+// openStore opens the version file given by --version-file.
+func openStore(creat bool) (ventry.Store, error) {
+	filename := viper.GetString(VFILE)
+	if len(filename) == 0 {
+		return nil, usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+	}
+	vp, err := ventry.Open(filename, creat)
+	if err != nil {
+		return nil, fmt.Errorf("Open failed on %s; %w", filename, err)
+	}
+	vp.SetHistory(viper.GetInt(HISTORY))
+	return vp, nil
+}
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			log.Fatalf("Config file was found but an error occured; %s", err)
+// explicit reports whether key was given on the command line or in
+// the environment (as opposed to taking its default).
+func explicit(key string) bool {
+	src := configSource(key)
+	return src == "flag" || src == "env"
+}
+
+// entryArg returns the entry named by the first argument or --entry.
+func entryArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return viper.GetString(ENTRY)
+}
+
+// explicitPrefix returns --prefix (or VERS_PREFIX) if it was given.
+func explicitPrefix() string {
+	if explicit(PREFIX) {
+		return viper.GetString(PREFIX)
+	}
+	return ""
+}
+
+// entryPrefix returns the prefix of entry in the project settings.
+func entryPrefix(entry string) string {
+	return entryString(entry, PREFIX)
+}
+
+// versionArg returns the version for entry, given either as the
+// second argument ("2.5.0", "v2.5.0-rc1") or with all of --major,
+// --minor and --patch.  When defaults is set and neither is given the
+// flag defaults are used, a partial set of flags is always refused.
+// The prefix is left empty unless it is given, in the argument or with
+// --prefix, for the caller to take from each entry's settings.
+func versionArg(entry string, args []string, defaults bool) (ventry.Vers, error) {
+	var given, missing []string
+	for _, k := range []string{MAJ, MIN, PATCH} {
+		if explicit(k) {
+			given = append(given, "--"+k)
+		} else {
+			missing = append(missing, "--"+k)
+		}
+	}
+	if len(args) > 1 {
+		if len(given) != 0 {
+			return ventry.Vers{}, usageErrorf("give the version as an argument or with %s, not both", strings.Join(given, ", "))
+		}
+		ve, err := ventry.Parse(args[1])
+		if err != nil {
+			return ventry.Vers{}, usageErrorf("%w", err)
+		}
+		if len(ve.Prefix) == 0 {
+			ve.Prefix = explicitPrefix()
+		} else if explicit(PREFIX) {
+			return ventry.Vers{}, usageErrorf("give the prefix in the version argument or with --%s, not both", PREFIX)
 		}
+		if explicit(SUFFIX) {
+			if len(ve.Suffix) != 0 {
+				return ventry.Vers{}, usageErrorf("give the suffix in the version argument or with --%s, not both", SUFFIX)
+			}
+			ve.Suffix = viper.GetString(SUFFIX)
+		}
+		return ve, nil
+	}
+	if len(missing) != 0 && (len(given) != 0 || !defaults) {
+		return ventry.Vers{}, usageErrorf("missing %s; give the version as an argument or with all of --%s, --%s and --%s",
+			strings.Join(missing, ", "), MAJ, MIN, PATCH)
 	}
+	return ventry.Vers{
+		Prefix: explicitPrefix(),
+		Major:  viper.GetInt(MAJ),
+		Minor:  viper.GetInt(MIN),
+		Patch:  viper.GetInt(PATCH),
+		Suffix: viper.GetString(SUFFIX),
+	}, nil
 }
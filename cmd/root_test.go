@@ -0,0 +1,257 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// sandbox is a temporary project directory the tests run vers in, with
+// HOME pointing at it and no VERS_ variables set.
+type sandbox struct {
+	t   *testing.T
+	dir string
+	wd  string
+	env map[string]*string
+}
+
+// newSandbox makes the sandbox the working directory, close puts
+// everything back.
+func newSandbox(t *testing.T) *sandbox {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "vers-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	s := &sandbox{t: t, dir: dir, wd: wd, env: make(map[string]*string)}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "VERS_") {
+			s.unsetenv(kv[:strings.Index(kv, "=")])
+		}
+	}
+	s.setenv("HOME", dir)
+	return s
+}
+
+// setenv sets an environment variable until close.
+func (s *sandbox) setenv(key, value string) {
+	s.save(key)
+	os.Setenv(key, value)
+}
+
+// unsetenv unsets an environment variable until close.
+func (s *sandbox) unsetenv(key string) {
+	s.save(key)
+	os.Unsetenv(key)
+}
+
+func (s *sandbox) save(key string) {
+	if _, ok := s.env[key]; ok {
+		return
+	}
+	if v, ok := os.LookupEnv(key); ok {
+		s.env[key] = &v
+	} else {
+		s.env[key] = nil
+	}
+}
+
+// write writes a file in the sandbox.
+func (s *sandbox) write(name, data string) {
+	s.t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(s.dir, name), []byte(data), 0644); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// vers runs vers and fails the test unless it exits with 0.
+func (s *sandbox) vers(args ...string) string {
+	s.t.Helper()
+	out, code := runVers(s.t, args...)
+	if code != exitOK {
+		s.t.Fatalf("vers %s: exit %d", strings.Join(args, " "), code)
+	}
+	return out
+}
+
+func (s *sandbox) close() {
+	for k, v := range s.env {
+		if v == nil {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, *v)
+		}
+	}
+	os.Chdir(s.wd)
+	os.RemoveAll(s.dir)
+}
+
+// resetFlags puts the flags given on an earlier run back to their
+// defaults.
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if f.Changed {
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	}
+	c.PersistentFlags().VisitAll(reset)
+	c.Flags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// resetConfig forgets the config read on an earlier run.
+func resetConfig(t *testing.T) {
+	t.Helper()
+	cfgSources, projectDir = make(map[string]string), ""
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(bytes.NewReader(nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runVers runs vers with args in process, returning what it wrote to
+// stdout and the code it would have exited with.
+func runVers(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	resetFlags(RootCmd)
+	started, cfgErr = false, nil
+	resetConfig(t)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- string(data)
+	}()
+	RootCmd.SetArgs(args)
+	err = RootCmd.Execute()
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Logf("vers %s: %s", strings.Join(args, " "), err)
+	}
+	return <-out, processExit(err)
+}
+
+func TestEnvOverrides(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "cfg.yaml", "-e", "api", "-M", "1", "-m", "0", "-p", "0")
+	s.vers("init", "-f", "env.yaml", "-e", "api", "-M", "2", "-m", "0", "-p", "0")
+	s.vers("init", "-f", "flag.yaml", "-e", "api", "-M", "3", "-m", "0", "-p", "0")
+	s.write(".vers.yaml", "version-file: cfg.yaml\nfmt: str\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string // prefix of the output
+	}{
+		{"config", nil, []string{"get", "-e", "api"}, "v1.0.0\n"},
+		{"env beats config",
+			map[string]string{"VERS_VERSION_FILE": "env.yaml"},
+			[]string{"get", "-e", "api"}, "v2.0.0\n"},
+		{"env fmt beats config",
+			map[string]string{"VERS_FMT": "yaml"},
+			[]string{"get", "-e", "api"}, "api:\n"},
+		{"env entry",
+			map[string]string{"VERS_ENTRY": "api", "VERS_VERSION_FILE": "env.yaml"},
+			[]string{"get"}, "v2.0.0\n"},
+		{"flag beats env",
+			map[string]string{"VERS_VERSION_FILE": "env.yaml"},
+			[]string{"get", "-e", "api", "-f", "flag.yaml"}, "v3.0.0\n"},
+		{"flag fmt beats env",
+			map[string]string{"VERS_FMT": "json"},
+			[]string{"get", "-e", "api", "-o", "yaml"}, "api:\n"},
+		{"unprefixed ignored",
+			map[string]string{"VERSION_FILE": "env.yaml", "FMT": "json", "ENTRY": "web"},
+			[]string{"get"}, "v1.0.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				s.setenv(k, v)
+			}
+			defer func() {
+				for k := range tt.env {
+					os.Unsetenv(k)
+				}
+			}()
+			if got := s.vers(tt.args...); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("got %q, want %q...", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigShowKeys(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.setenv("VERS_SCHEME", "semver")
+
+	out := s.vers("config", "show")
+	if strings.Contains(out, "<nil>") {
+		t.Errorf("config show lists unset keys:\n%s", out)
+	}
+	var scheme bool
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == "help" {
+			t.Errorf("config show lists the help flag:\n%s", out)
+		}
+		if f[0] == SCHEME {
+			scheme = len(f) == 3 && f[1] == "semver" && f[2] == "env"
+		}
+	}
+	if !scheme {
+		t.Errorf("config show does not give scheme semver from env:\n%s", out)
+	}
+}
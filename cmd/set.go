@@ -23,7 +23,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/apex/log"
 	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -32,48 +31,83 @@ import (
 var (
 	// setCmd represents the set command
 	setCmd = &cobra.Command{
-		Use:   "set",
+		Use:   "set [entry [version]]",
 		Short: "Add a new entry to version file",
-		Long:  `This will add an entry (or update an existing) to the version file`,
-		Run:   set,
+		Long: `This will add an entry (or update an existing) to the version file
+
+  vers set api 2.5.0
+  vers set -e api -M 2 -m 5 -p 0
+
+The version is either the second argument or all of --major, --minor
+and --patch.  Setting a member of a group (see vers group), or "@group",
+sets every member.`,
+		Args:   cobra.MaximumNArgs(2),
+		PreRun: bindFlags,
+		RunE:   set,
 	}
 )
 
 func init() {
+	setCmd.Flags().Bool(SYNC, false, "sync the entry's manifests (see vers sync)")
+
 	RootCmd.AddCommand(setCmd)
 }
 
-func set(cmd *cobra.Command, args []string) {
-	if viper.GetBool(DEBUG) {
-		fmt.Println("Set Debug")
-		log.SetLevel(log.DebugLevel)
-	}
+func set(cmd *cobra.Command, args []string) error {
 	filename := viper.GetString(VFILE)
-	if len(filename) == 0 {
-		log.Fatalf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
-	}
-	entry := viper.GetString(ENTRY)
+	entry := entryArg(args)
 	if len(entry) == 0 {
-		log.Fatalf("you must supply entry name (--%s)", ENTRY)
+		return usageErrorf("you must supply entry name (--%s)", ENTRY)
+	}
+	ve, err := versionArg(entry, args, false)
+	if err != nil {
+		return err
 	}
-	vp, err := ventry.Open(filename, false)
+	vs, err := openStore(false)
 	if err != nil {
-		log.Fatalf("Failed to open %s; %s", filename, err)
+		return err
 	}
-	defer vp.Close()
-	if err := vp.Read(10); err != nil {
-		log.Infof("Failed to read %s; %s", filename, err)
-		return
+	defer vs.Close()
+	if err := ve.Check(entryString(entry, SCHEME)); err != nil {
+		return fmt.Errorf("Invalid version for %s; %w", entry, err)
 	}
-	vp.Add(entry, &ventry.Vers{
-		Prefix: viper.GetString(PREFIX),
-		Major:  viper.GetInt(MAJ),
-		Minor:  viper.GetInt(MIN),
-		Patch:  viper.GetInt(PATCH),
-		Suffix: viper.GetString(SUFFIX),
+	hv := ve
+	if len(hv.Prefix) == 0 {
+		hv.Prefix = entryPrefix(entry)
+	}
+	if err := runHooks("pre-set", entry, hv.String()); err != nil {
+		return policyErrorf("Set failed on %s; %w", filename, err)
+	}
+	// each member of a group is checked and gets its own prefix
+	var (
+		names []string
+		vers  []ventry.Vers
+	)
+	err = vs.Update(func(f *ventry.VFile) error {
+		if names, err = f.SetAll(entry, ve, entryPrefix); err != nil {
+			return err
+		}
+		for _, n := range names {
+			nv := *f.Version[n]
+			if err := nv.Check(entryString(n, SCHEME)); err != nil {
+				return fmt.Errorf("Invalid version for %s; %w", n, err)
+			}
+			vers = append(vers, nv)
+		}
+		return nil
 	})
-	if err = vp.Write(3); err != nil {
-		log.Infof("Failed to write %s; %s", filename, err)
+	if err != nil {
+		return fmt.Errorf("Failed to write %s; %w", filename, err)
 	}
-
+	for i, n := range names {
+		if err := autoSync(n, vers[i]); err != nil {
+			return err
+		}
+	}
+	for i, n := range names {
+		if err := runHooks("post-set", n, vers[i].String()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
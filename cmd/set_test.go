@@ -0,0 +1,80 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionArg(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"positional", []string{"set", "api", "2.5.0"}, exitOK, "v2.5.0"},
+		{"positional prefix and suffix", []string{"set", "api", "api-v2.5.0-rc1"}, exitOK, "api-v2.5.0-rc1"},
+		{"positional and --prefix", []string{"set", "api", "2.5.0", "--prefix", "api-v"}, exitOK, "api-v2.5.0"},
+		{"positional and --suffix", []string{"set", "api", "2.5.0", "--suffix", "-rc2"}, exitOK, "v2.5.0-rc2"},
+		{"entry flag", []string{"set", "-e", "api", "-M", "2", "-m", "5", "-p", "0"}, exitOK, "v2.5.0"},
+		{"all flags", []string{"set", "api", "-M", "3", "-m", "1", "-p", "4"}, exitOK, "v3.1.4"},
+		{"major only", []string{"set", "api", "-M", "3"}, exitUsage, "v1.0.0"},
+		{"major and minor", []string{"set", "api", "-M", "3", "-m", "1"}, exitUsage, "v1.0.0"},
+		{"patch only", []string{"set", "api", "-p", "9"}, exitUsage, "v1.0.0"},
+		{"positional and flags", []string{"set", "api", "2.5.0", "-M", "3"}, exitUsage, "v1.0.0"},
+		{"two prefixes", []string{"set", "api", "v2.5.0", "--prefix", "api-v"}, exitUsage, "v1.0.0"},
+		{"two suffixes", []string{"set", "api", "2.5.0-rc1", "--suffix", "-rc2"}, exitUsage, "v1.0.0"},
+		{"no version", []string{"set", "api"}, exitUsage, "v1.0.0"},
+		{"bad version", []string{"set", "api", "two.five"}, exitUsage, "v1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSandbox(t)
+			defer s.close()
+			s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+			args := append(tt.args[:1:1], append([]string{"-f", "v.yaml"}, tt.args[1:]...)...)
+			if _, code := runVers(t, args...); code != tt.code {
+				t.Errorf("vers %s exited %d, want %d", strings.Join(tt.args, " "), code, tt.code)
+			}
+			if got := strings.TrimSpace(s.vers("get", "-f", "v.yaml", "-o", "str", "api")); got != tt.want {
+				t.Errorf("after vers %s api is %s, want %s", strings.Join(tt.args, " "), got, tt.want)
+			}
+		})
+	}
+
+	t.Run("init defaults", func(t *testing.T) {
+		s := newSandbox(t)
+		defer s.close()
+		s.vers("init", "-f", "v.yaml", "api")
+		s.vers("init", "-f", "w.yaml", "web", "-M", "2", "-m", "0", "-p", "0")
+		if got := strings.TrimSpace(s.vers("get", "-f", "v.yaml", "-o", "str", "api")); got != "v0.0.1" {
+			t.Errorf("init without a version gave %s, want v0.0.1", got)
+		}
+		if got := strings.TrimSpace(s.vers("get", "-f", "w.yaml", "-o", "str", "web")); got != "v2.0.0" {
+			t.Errorf("init -M 2 -m 0 -p 0 gave %s, want v2.0.0", got)
+		}
+		if _, code := runVers(t, "init", "-f", "x.yaml", "cli", "-m", "3"); code != exitUsage {
+			t.Errorf("init -m 3 exited %d, want %d", code, exitUsage)
+		}
+	})
+}
@@ -0,0 +1,396 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/rbg/vers/manifest"
+	"github.com/rbg/vers/ventry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// syncCmd represents the sync command
+	syncCmd = &cobra.Command{
+		Use:   "sync [entry...]",
+		Short: "Copy versions into package.json, Cargo.toml, Chart.yaml, ...",
+		Long: `Copy each entry's version into the manifest files listed for it in
+the project config, leaving the rest of those files as they are:
+
+  entries:
+    api:
+      sync:
+        - file: web/package.json        # "version"
+        - file: Cargo.toml              # [package] version
+        - file: pyproject.toml          # [project] version
+        - file: charts/api/Chart.yaml   # version
+        - file: charts/api/Chart.yaml
+          key: appVersion
+          format: "{{.Version}}"
+
+The type (json, toml or yaml) and key come from the file name when not
+given, toml keys are table.key (tool.poetry.version).  The value is the
+version without its prefix unless a format template is given.
+
+With auto-sync set in the config (or --sync) bump and set sync the
+entry they change.
+
+With --check nothing is written, the manifests and the files of the
+entries' search and replace rules (see vers bump) that do not agree
+with the version file are listed and vers exits with 8.`,
+		Args:   cobra.ArbitraryArgs,
+		PreRun: bindFlags,
+		RunE:   syncVersions,
+	}
+)
+
+func init() {
+	syncCmd.Flags().Bool(CHECK, false, "report files out of sync instead of writing them")
+
+	RootCmd.AddCommand(syncCmd)
+}
+
+// syncChange is the update of one version in a manifest.
+type syncChange struct {
+	Entry string
+	File  string
+	Old   string
+	New   string
+}
+
+// syncPlan collects manifest updates in memory so several updates of
+// one file build on each other and nothing is written until they have
+// all been worked out.
+type syncPlan struct {
+	changes []syncChange
+	files   map[string][]byte
+	orig    map[string][]byte
+	created map[string]bool
+	order   []string
+	written []string
+	// report is set when only checking, a manifest that is missing or
+	// has no version is then drift rather than an error.
+	report bool
+	broken []syncDrift
+}
+
+func newSyncPlan() *syncPlan {
+	return &syncPlan{
+		files:   make(map[string][]byte),
+		orig:    make(map[string][]byte),
+		created: make(map[string]bool),
+	}
+}
+
+// read returns the pending contents of a file.
+func (p *syncPlan) read(path string) ([]byte, error) {
+	if data, ok := p.files[path]; ok {
+		return data, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p.files[path] = data
+	p.orig[path] = data
+	p.order = append(p.order, path)
+	return data, nil
+}
+
+// readOrCreate is read for a file that is created if need be.
+func (p *syncPlan) readOrCreate(path string) ([]byte, error) {
+	data, err := p.read(path)
+	if os.IsNotExist(err) {
+		p.files[path], p.orig[path], p.created[path] = nil, nil, true
+		p.order = append(p.order, path)
+		return nil, nil
+	}
+	return data, err
+}
+
+// relPath is path as it is shown to the user.
+func relPath(path string) string {
+	if len(projectDir) != 0 {
+		if rel, err := filepath.Rel(projectDir, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// syncProblem describes why a manifest can't be synced.
+func syncProblem(err error) string {
+	if os.IsNotExist(err) {
+		return "is missing"
+	}
+	// the path is already shown
+	if e := errors.Unwrap(err); e != nil {
+		return e.Error()
+	}
+	return err.Error()
+}
+
+// add works out the manifest updates for an entry.
+func (p *syncPlan) add(name string, ve ventry.Vers) error {
+	es, err := entrySettings(name)
+	if err != nil {
+		return err
+	}
+	for _, s := range es.Sync {
+		if len(s.File) == 0 {
+			return fmt.Errorf("%s.%s.sync; every item needs a file", ENTRIES, name)
+		}
+		f := s.Format
+		if len(f) == 0 {
+			f = "{{.Semver}}"
+		}
+		t, err := ventry.NewTemplate(s.File, f)
+		if err != nil {
+			return err
+		}
+		val, err := ventry.ExecTemplate(t, name, ve)
+		if err != nil {
+			return err
+		}
+		mt := manifest.Target{Path: projectPath(s.File), Type: s.Type, Key: s.Key}
+		data, err := p.read(mt.Path)
+		var old string
+		if err == nil {
+			data, old, err = mt.Update(data, val)
+		}
+		if err != nil {
+			if !p.report {
+				return err
+			}
+			p.broken = append(p.broken, syncDrift{Entry: name, File: s.File, Detail: syncProblem(err)})
+			continue
+		}
+		p.files[mt.Path] = data
+		p.changes = append(p.changes, syncChange{Entry: name, File: s.File, Old: old, New: val})
+	}
+	return nil
+}
+
+// replace works out the search and replace rules for a change of an
+// entry from old to nv.
+func (p *syncPlan) replace(name string, old, nv ventry.Vers) error {
+	es, err := entrySettings(name)
+	if err != nil {
+		return err
+	}
+	for _, s := range es.Replace {
+		r, err := newReplaceRule(s, name, old, nv)
+		if err != nil {
+			return err
+		}
+		files, err := r.files()
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			data, err := p.read(path)
+			if err != nil {
+				return err
+			}
+			if data, err = r.apply(path, data); err != nil {
+				return err
+			}
+			p.files[path] = data
+			p.changes = append(p.changes, syncChange{Entry: name, File: relPath(path), Old: old.Semver(), New: nv.Semver()})
+		}
+	}
+	return nil
+}
+
+// syncDrift is a file that does not agree with the version file.
+type syncDrift struct {
+	Entry  string
+	File   string
+	Detail string
+}
+
+func (d syncDrift) String() string {
+	return fmt.Sprintf("%s: %s %s", d.Entry, d.File, d.Detail)
+}
+
+// drift returns the entry's manifests (added to the plan already) and
+// the files its search and replace rules do not find its version in.
+func (p *syncPlan) drift(name string, ve ventry.Vers) ([]syncDrift, error) {
+	var out []syncDrift
+
+	for _, d := range p.broken {
+		if d.Entry == name {
+			out = append(out, d)
+		}
+	}
+	for _, c := range p.changes {
+		if c.Entry == name && c.Old != c.New {
+			out = append(out, syncDrift{Entry: name, File: c.File, Detail: fmt.Sprintf("has %s, want %s", c.Old, c.New)})
+		}
+	}
+	es, err := entrySettings(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range es.Replace {
+		r, err := newReplaceRule(s, name, ve, ve)
+		if err != nil {
+			return nil, err
+		}
+		files, err := r.files()
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			data, err := p.read(path)
+			if err != nil {
+				return nil, err
+			}
+			if d := r.check(data); len(d) != 0 {
+				out = append(out, syncDrift{Entry: name, File: relPath(path), Detail: d})
+			}
+		}
+	}
+	return out, nil
+}
+
+// apply writes the files that changed, all of them or (restoring those
+// already written) none.
+func (p *syncPlan) apply() error {
+	for _, path := range p.order {
+		if !p.created[path] && bytes.Equal(p.orig[path], p.files[path]) {
+			continue
+		}
+		if err := manifest.WriteFile(path, p.files[path]); err != nil {
+			p.rollback()
+			return err
+		}
+		p.written = append(p.written, path)
+	}
+	return nil
+}
+
+// rollback restores the files apply wrote.
+func (p *syncPlan) rollback() {
+	for _, path := range p.written {
+		if p.created[path] {
+			if err := os.Remove(path); err != nil {
+				log.Errorf("failed to remove %s; %s", path, err)
+			}
+			continue
+		}
+		if err := manifest.WriteFile(path, p.orig[path]); err != nil {
+			log.Errorf("failed to restore %s; %s", path, err)
+		}
+	}
+	p.written = nil
+}
+
+// print reports the changes.
+func (p *syncPlan) print() {
+	for _, c := range p.changes {
+		if c.Old == c.New {
+			fmt.Printf("%s: %s is up to date (%s)\n", c.Entry, c.File, c.New)
+			continue
+		}
+		fmt.Printf("%s: %s %s -> %s\n", c.Entry, c.File, c.Old, c.New)
+	}
+}
+
+// syncWanted reports whether bump and set should sync the manifests.
+func syncWanted() bool {
+	return viper.GetBool(SYNC) || viper.GetBool(AUTOSYNC)
+}
+
+// autoSync syncs an entry after set when asked to.
+func autoSync(name string, ve ventry.Vers) error {
+	if !syncWanted() {
+		return nil
+	}
+	p := newSyncPlan()
+	if err := p.add(name, ve); err != nil {
+		return err
+	}
+	return p.apply()
+}
+
+func syncVersions(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	ents, err := vs.List()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	names := args
+	if len(names) == 0 {
+		names = ents.Names()
+	}
+	p := newSyncPlan()
+	p.report = viper.GetBool(CHECK)
+	for _, name := range names {
+		ve, ok := ents[name]
+		if !ok {
+			return &ventry.EntryError{Name: name, Err: ventry.ErrEntryNotFound}
+		}
+		if err := p.add(name, *ve); err != nil {
+			return err
+		}
+	}
+	if viper.GetBool(CHECK) {
+		return p.check(ents, names)
+	}
+	if err := p.apply(); err != nil {
+		return err
+	}
+	p.print()
+	return nil
+}
+
+// check reports the manifests and search and replace files that do not
+// agree with the version file, without changing them.
+func (p *syncPlan) check(ents ventry.Entries, names []string) error {
+	var drift []syncDrift
+	for _, name := range names {
+		d, err := p.drift(name, *ents[name])
+		if err != nil {
+			return err
+		}
+		drift = append(drift, d...)
+	}
+	for _, d := range drift {
+		fmt.Println(d)
+	}
+	if len(drift) != 0 {
+		return policyErrorf("%d file(s) out of sync with %s", len(drift), viper.GetString(VFILE))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultTagTemplate = "{{.Version}}"
+	defaultTagMessage  = "{{.Name}} {{.Version}}"
+)
+
+var (
+	// tagCmd represents the tag command
+	tagCmd = &cobra.Command{
+		Use:   "tag [entry]",
+		Short: "Create an annotated git tag of HEAD for an entry",
+		Long: `Create an annotated (with --sign, a signed) git tag of HEAD named
+after the entry's version.  The name and message are templates, set in
+the project config for all entries or per entry:
+
+  tag-template: "{{.Version}}"
+  entries:
+    api:
+      tag-template: "api/v{{version}}"
+      tag-message: "api release {{.Version}}"
+
+vers refuses to tag if the work tree has uncommitted changes (exit 8)
+or the tag exists already (exit 7).`,
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: bindFlags,
+		RunE:   tag,
+	}
+)
+
+func init() {
+	tagCmd.Flags().String(TAGTMPL, defaultTagTemplate, "template for the tag name")
+	tagCmd.Flags().String(TAGMSG, defaultTagMessage, "template for the tag message")
+	tagCmd.Flags().BoolP(SIGN, "s", false, "make a GPG signed tag")
+
+	viper.SetDefault(TAGTMPL, defaultTagTemplate)
+	viper.SetDefault(TAGMSG, defaultTagMessage)
+
+	RootCmd.AddCommand(tagCmd)
+}
+
+// gitRepo opens the repository holding the version file.
+func gitRepo() (*vgit.Repo, error) {
+	return vgit.Open(filepath.Dir(viper.GetString(VFILE)))
+}
+
+// entryTemplate renders the template setting key for an entry.
+func entryTemplate(entry, key string, ve ventry.Vers) (string, error) {
+	t, err := ventry.NewTemplate(key, entryString(entry, key))
+	if err != nil {
+		return "", err
+	}
+	return ventry.ExecTemplate(t, entry, ve)
+}
+
+// checkClean refuses a work tree with uncommitted changes.
+func checkClean(r *vgit.Repo) error {
+	dirty, err := r.Dirty()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return policyErrorf("%w; commit or stash them first", vgit.ErrDirty)
+	}
+	return nil
+}
+
+// tagName returns the tag for an entry, refusing one that exists.
+func tagName(r *vgit.Repo, entry string, ve ventry.Vers) (string, error) {
+	name, err := entryTemplate(entry, TAGTMPL, ve)
+	if err != nil {
+		return "", err
+	}
+	ok, err := r.TagExists(name)
+	if err != nil {
+		return "", usageErrorf("%w", err)
+	}
+	if ok {
+		return "", conflictErrorf("%s; %w", name, vgit.ErrTagExists)
+	}
+	return name, nil
+}
+
+// makeTag tags HEAD for an entry.
+func makeTag(r *vgit.Repo, name, entry string, ve ventry.Vers) error {
+	msg, err := entryTemplate(entry, TAGMSG, ve)
+	if err != nil {
+		return err
+	}
+	if err := r.Tag(name, msg, viper.GetBool(SIGN)); err != nil {
+		if errors.Is(err, vgit.ErrTagExists) {
+			return conflictErrorf("%w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func tag(cmd *cobra.Command, args []string) error {
+	entry := entryArg(args)
+	if len(entry) == 0 {
+		return usageErrorf("you must supply entry name (--%s)", ENTRY)
+	}
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	ve, err := vs.Get(entry)
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	r, err := gitRepo()
+	if err != nil {
+		return err
+	}
+	if err := checkClean(r); err != nil {
+		return err
+	}
+	name, err := tagName(r, entry, ve)
+	if err != nil {
+		return err
+	}
+	if err := makeTag(r, name, entry, ve); err != nil {
+		return err
+	}
+	fmt.Println(name)
+	return nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// gitSandbox is a sandbox holding an empty git repository.
+func gitSandbox(t *testing.T) *sandbox {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	s := newSandbox(t)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=vers", "GIT_AUTHOR_EMAIL=vers@example.com",
+		"GIT_COMMITTER_NAME=vers", "GIT_COMMITTER_EMAIL=vers@example.com"} {
+		i := strings.Index(kv, "=")
+		s.setenv(kv[:i], kv[i+1:])
+	}
+	s.git("init", "-q")
+	s.git("config", "commit.gpgsign", "false")
+	s.git("config", "tag.gpgsign", "false")
+	return s
+}
+
+// git runs git in the sandbox and returns its output.
+func (s *sandbox) git(args ...string) string {
+	s.t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = s.dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		s.t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit commits everything in the sandbox.
+func (s *sandbox) commit(msg string) {
+	s.t.Helper()
+	s.git("add", "-A")
+	s.git("commit", "-q", "-m", msg)
+}
+
+func TestTag(t *testing.T) {
+	s := gitSandbox(t)
+	defer s.close()
+	s.write(".vers.yaml", `entries:
+  api:
+    tag-template: "api/v{{version}}"
+    tag-message: "api release {{.Version}}"
+`)
+	s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+	s.vers("set", "-f", "v.yaml", "web", "2.0.0")
+	s.commit("start")
+	head := s.git("rev-parse", "HEAD")
+
+	if out := s.vers("tag", "-f", "v.yaml", "api"); out != "api/v1.2.0\n" {
+		t.Errorf("tag api printed %q, want %q", out, "api/v1.2.0\n")
+	}
+	if typ := s.git("cat-file", "-t", "api/v1.2.0"); typ != "tag" {
+		t.Errorf("api/v1.2.0 is a %s, want an annotated tag", typ)
+	}
+	if msg := s.git("tag", "-l", "--format=%(contents:subject)", "api/v1.2.0"); msg != "api release v1.2.0" {
+		t.Errorf("api/v1.2.0 message is %q, want %q", msg, "api release v1.2.0")
+	}
+	if c := s.git("rev-parse", "api/v1.2.0^{commit}"); c != head {
+		t.Errorf("api/v1.2.0 is on %s, want HEAD %s", c, head)
+	}
+
+	s.vers("tag", "-f", "v.yaml", "web")
+	if msg := s.git("tag", "-l", "--format=%(contents:subject)", "v2.0.0"); msg != "web v2.0.0" {
+		t.Errorf("web tag message is %q, want %q", msg, "web v2.0.0")
+	}
+
+	if _, code := runVers(t, "tag", "-f", "v.yaml", "api"); code != exitConflict {
+		t.Errorf("tagging api again exited %d, want %d", code, exitConflict)
+	}
+
+	s.vers("bump", "-f", "v.yaml", "api", "minor")
+	if _, code := runVers(t, "tag", "-f", "v.yaml", "api"); code != exitPolicy {
+		t.Errorf("tagging a dirty tree exited %d, want %d", code, exitPolicy)
+	}
+	if out := s.git("tag", "-l", "api/*"); out != "api/v1.2.0" {
+		t.Errorf("tags after the refusals are %q, want only api/v1.2.0", out)
+	}
+}
+
+func TestBumpTag(t *testing.T) {
+	s := gitSandbox(t)
+	defer s.close()
+	s.vers("init", "-f", "v.yaml", "api", "1.2.0")
+	s.commit("start")
+
+	s.vers("bump", "-f", "v.yaml", "api", "patch", "--tag")
+	if typ := s.git("cat-file", "-t", "v1.2.1"); typ != "tag" {
+		t.Errorf("v1.2.1 is a %s, want an annotated tag", typ)
+	}
+	if c, head := s.git("rev-parse", "v1.2.1^{commit}"), s.git("rev-parse", "HEAD"); c != head {
+		t.Errorf("v1.2.1 is on %s, want HEAD %s", c, head)
+	}
+	if st := s.git("status", "--porcelain"); len(st) != 0 {
+		t.Errorf("bump --tag left changes behind:\n%s", st)
+	}
+}
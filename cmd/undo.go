@@ -23,7 +23,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/apex/log"
 	"github.com/rbg/vers/ventry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -31,38 +30,71 @@ import (
 
 // undoCmd represents the undo command
 var undoCmd = &cobra.Command{
-	Use:   "undo",
+	Use:   "undo [entry]",
 	Short: "Undo last set or bump for entry",
-	Long:  "Undo last set or bump for entry",
+	Long: `Undo last set or bump for entry, for every member if it is in a group
+(see vers group) or "@group" is given`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if viper.GetBool(DEBUG) {
-			log.SetLevel(log.DebugLevel)
+		if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+			return usageErrorf("%s", err)
 		}
 		if len(viper.GetString(VFILE)) == 0 {
-			return fmt.Errorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
+			return usageErrorf("you must supply the .json or .yaml version file pathname (--%s)", VFILE)
 		}
-		if len(viper.GetString(ENTRY)) == 0 {
-			return fmt.Errorf("you must supply the entry name (--%s)", ENTRY)
+		if len(entryArg(args)) == 0 {
+			return usageErrorf("you must supply the entry name (--%s)", ENTRY)
 		}
 		return nil
 	},
-	Run: undo,
+	RunE: undo,
 }
 
 func init() {
 	RootCmd.AddCommand(undoCmd)
 }
 
-func undo(cmd *cobra.Command, args []string) {
+func undo(cmd *cobra.Command, args []string) error {
 
-	vp, err := ventry.Open(viper.GetString(VFILE), false)
+	vs, err := openStore(false)
 	if err != nil {
-		log.Fatalf("Open failed on %s; %s", viper.GetString(VFILE), err)
+		return err
 	}
-	defer vp.Close()
-	if err = vp.Undo(viper.GetString(ENTRY)); err != nil {
-		log.Infof("Undo failed on %s; %s", viper.GetString(VFILE), err)
-		return
+	defer vs.Close()
+	entry := entryArg(args)
+	if err := runHooks("pre-undo", entry, ""); err != nil {
+		return policyErrorf("Undo failed on %s; %w", viper.GetString(VFILE), err)
 	}
-	vp.Print(viper.GetString(ENTRY), "str")
+	var (
+		names []string
+		vers  []ventry.Vers
+	)
+	err = vs.Update(func(f *ventry.VFile) error {
+		if names, err = f.Resolve(entry); err != nil {
+			return err
+		}
+		for _, n := range names {
+			ve, err := f.Undo(n)
+			if err != nil {
+				return err
+			}
+			vers = append(vers, ve)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Undo failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	for i, n := range names {
+		if len(names) > 1 {
+			fmt.Printf("%s %s\n", n, vers[i])
+		} else {
+			fmt.Println(vers[i])
+		}
+	}
+	for i, n := range names {
+		if err := runHooks("post-undo", n, vers[i].String()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,228 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rbg/vers/ventry"
+	"github.com/rbg/vers/vgit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// verifyCmd represents the verify command
+	verifyCmd = &cobra.Command{
+		Use:   "verify [entry...]",
+		Short: "Check the version file against git tags and manifests",
+		Long: `Check each entry (all without arguments) is a valid SemVer version,
+is the version of its latest tag matching its tag template (see vers
+tag) and agrees with the manifests and files it is synced or replaced
+into (see vers sync and vers bump) and, in a group (see vers group), has
+the version of the other members.  vers exits with 8 if any of them do
+not agree, for a pre-push hook or CI:
+
+  $ vers verify
+  ENTRY  CHECK   STATUS  DETAIL
+  api    semver  ok      v2.1.0
+  api    tag     drift   api/v2.0.3 is the latest tag
+  api    file    ok      web/package.json
+
+A missing manifest, or one without its version key, is drift too.  An
+entry without tags or a version file outside of a git repository is not
+an error, the tag check is skipped.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: verify,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(verifyCmd)
+}
+
+// verifyResult is the outcome of one check of an entry.
+type verifyResult struct {
+	Entry  string
+	Check  string
+	Status string
+	Detail string
+}
+
+// latestTag returns the entry's tag with the highest version.
+func latestTag(r *vgit.Repo, entry string) (string, ventry.Vers, error) {
+	var (
+		latest string
+		lv     ventry.Vers
+	)
+	glob, head, tail, err := tagPattern(entry)
+	if err != nil {
+		return "", lv, err
+	}
+	tags, err := r.Tags(glob)
+	if err != nil {
+		return "", lv, err
+	}
+	for _, t := range tags {
+		ve, err := ventry.Parse(strings.TrimSuffix(strings.TrimPrefix(t, head), tail))
+		if err != nil {
+			continue
+		}
+		if len(latest) == 0 || ventry.Compare(ve, lv) > 0 {
+			latest, lv = t, ve
+		}
+	}
+	return latest, lv, nil
+}
+
+// verifyEntry runs the checks of an entry, r is nil outside of a git
+// repository.
+func verifyEntry(r *vgit.Repo, name string, ve ventry.Vers) ([]verifyResult, error) {
+	var out []verifyResult
+	result := func(check, status, detail string) {
+		out = append(out, verifyResult{Entry: name, Check: check, Status: status, Detail: detail})
+	}
+
+	if err := ve.Check(ventry.SchemeSemver); err != nil {
+		result("semver", "drift", err.Error())
+	} else {
+		result("semver", "ok", ve.String())
+	}
+
+	switch {
+	case r == nil:
+		result("tag", "skip", "not a git repository")
+	default:
+		tag, tv, err := latestTag(r, name)
+		switch {
+		case err != nil:
+			return nil, err
+		case len(tag) == 0:
+			result("tag", "skip", "no tags")
+		case ventry.Compare(ve, tv) != 0:
+			result("tag", "drift", tag+" is the latest tag")
+		default:
+			result("tag", "ok", tag)
+		}
+	}
+
+	p := newSyncPlan()
+	p.report = true
+	if err := p.add(name, ve); err != nil {
+		return nil, err
+	}
+	drift, err := p.drift(name, ve)
+	if err != nil {
+		return nil, err
+	}
+	bad := make(map[string]bool)
+	for _, d := range drift {
+		bad[d.File] = true
+		result("file", "drift", d.File+" "+d.Detail)
+	}
+	for _, c := range p.changes {
+		if !bad[c.File] {
+			result("file", "ok", c.File)
+		}
+	}
+	return out, nil
+}
+
+// verifyGroup checks that an entry in a group has the same version as
+// the other members.
+func verifyGroup(f *ventry.VFile, name string) []verifyResult {
+	g := f.GroupOf(name)
+	if len(g) == 0 {
+		return nil
+	}
+	ve := f.Version[name]
+	for _, m := range f.Groups[g] {
+		if mv, ok := f.Version[m]; ok && mv.Semver() != ve.Semver() {
+			return []verifyResult{{Entry: name, Check: "group", Status: "drift",
+				Detail: fmt.Sprintf("%s%s member %s is %s", ventry.GroupPrefix, g, m, mv)}}
+		}
+	}
+	return []verifyResult{{Entry: name, Check: "group", Status: "ok", Detail: ventry.GroupPrefix + g}}
+}
+
+func verify(cmd *cobra.Command, args []string) error {
+	vs, err := openStore(false)
+	if err != nil {
+		return err
+	}
+	vf, err := vs.Snapshot()
+	vs.Close()
+	if err != nil {
+		return fmt.Errorf("Read failed on %s; %w", viper.GetString(VFILE), err)
+	}
+	ents := vf.Version
+	names := args
+	if len(names) == 0 {
+		names = ents.Names()
+	}
+	r, err := gitRepo()
+	if err != nil {
+		r = nil
+	}
+
+	var (
+		results []verifyResult
+		drift   int
+	)
+	for _, name := range names {
+		ve, ok := ents[name]
+		if !ok {
+			return &ventry.EntryError{Name: name, Err: ventry.ErrEntryNotFound}
+		}
+		res, err := verifyEntry(r, name, *ve)
+		if err != nil {
+			return err
+		}
+		results = append(results, res...)
+		results = append(results, verifyGroup(vf, name)...)
+	}
+	for _, res := range results {
+		if res.Status == "drift" {
+			drift++
+		}
+	}
+
+	err = writeFormatted("table", results, map[string]func() error{
+		"table": func() error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ENTRY\tCHECK\tSTATUS\tDETAIL")
+			for _, res := range results {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.Entry, res.Check, res.Status, res.Detail)
+			}
+			return w.Flush()
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if drift != 0 {
+		return policyErrorf("%d check(s) found drift", drift)
+	}
+	return nil
+}
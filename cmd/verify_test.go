@@ -0,0 +1,84 @@
+package cmd
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVerifyManifestDrift(t *testing.T) {
+	s := newSandbox(t)
+	defer s.close()
+	s.write(".vers.yaml", `entries:
+  api:
+    sync:
+      - file: api.json
+  web:
+    sync:
+      - file: web.json
+  cli:
+    sync:
+      - file: cli.json
+`)
+	s.write("web.json", "{\"name\": \"web\"}\n")
+	s.write("cli.json", "{\"version\": \"1.0.0\"}\n")
+	s.vers("init", "-f", "v.yaml", "api", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "web", "1.0.0")
+	s.vers("set", "-f", "v.yaml", "cli", "1.0.0")
+
+	out, code := runVers(t, "verify", "-f", "v.yaml", "-o", "json")
+	if code != exitPolicy {
+		t.Errorf("exit %d, want %d", code, exitPolicy)
+	}
+	var results []verifyResult
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	files := make(map[string]verifyResult)
+	for _, r := range results {
+		if r.Check == "file" {
+			files[r.Entry] = r
+		}
+	}
+	want := map[string]string{
+		"api": "drift api.json is missing",
+		"web": "drift web.json version; version key not found",
+		"cli": "ok cli.json",
+	}
+	for e, w := range want {
+		if got := files[e].Status + " " + files[e].Detail; got != w {
+			t.Errorf("%s: %q, want %q", e, got, w)
+		}
+	}
+
+	// sync --check reports them the same way
+	out, code = runVers(t, "sync", "-f", "v.yaml", "--check")
+	if code != exitPolicy {
+		t.Errorf("sync --check exit %d, want %d", code, exitPolicy)
+	}
+	for _, w := range []string{"api: api.json is missing", "web: web.json version; version key not found"} {
+		if !strings.Contains(out, w) {
+			t.Errorf("sync --check does not report %q:\n%s", w, out)
+		}
+	}
+}
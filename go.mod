@@ -6,6 +6,7 @@ require (
 	github.com/apex/log v1.1.2
 	github.com/gofrs/flock v0.7.1
 	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.6.2
 	golang.org/x/sys v0.0.0-20200217220822-9197077df867
 	gopkg.in/yaml.v2 v2.2.8
@@ -0,0 +1,135 @@
+package manifest
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// jsonUpdater handles a top level string key in a json object.
+type jsonUpdater struct{}
+
+// jsonString returns the end (one past the closing quote) of the
+// string literal starting at data[i].
+func jsonString(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated string")
+}
+
+// skipSpace returns the index of the first non white space from i.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// find returns the start and end of the string value of the top level
+// key, which must be in the object once.
+func (jsonUpdater) find(data []byte, key string) (int, int, error) {
+	depth := 0
+	expectKey := false
+	vstart, vend := -1, -1
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '{', '[':
+			depth++
+			expectKey = depth == 1 && c == '{'
+		case '}', ']':
+			depth--
+		case ',':
+			expectKey = depth == 1
+		case '"':
+			end, err := jsonString(data, i)
+			if err != nil {
+				return 0, 0, err
+			}
+			if !expectKey {
+				i = end - 1
+				continue
+			}
+			expectKey = false
+			var name string
+			if err := json.Unmarshal(data[i:end], &name); err != nil {
+				return 0, 0, err
+			}
+			j := skipSpace(data, end)
+			if j >= len(data) || data[j] != ':' {
+				return 0, 0, errors.New("malformed object")
+			}
+			j = skipSpace(data, j+1)
+			if name != key {
+				i = j - 1
+				continue
+			}
+			if j >= len(data) || data[j] != '"' {
+				return 0, 0, fmt.Errorf("%s; is not a string", key)
+			}
+			if vstart >= 0 {
+				return 0, 0, fmt.Errorf("%s; %w", key, ErrDuplicateKey)
+			}
+			if vend, err = jsonString(data, j); err != nil {
+				return 0, 0, err
+			}
+			vstart = j
+			i = vend - 1
+		}
+	}
+	if vstart < 0 {
+		return 0, 0, fmt.Errorf("%s; %w", key, ErrKeyNotFound)
+	}
+	return vstart, vend, nil
+}
+
+func (u jsonUpdater) Read(data []byte, key string) (string, error) {
+	start, end, err := u.find(data, key)
+	if err != nil {
+		return "", err
+	}
+	var v string
+	if err := json.Unmarshal(data[start:end], &v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+func (u jsonUpdater) Write(data []byte, key, value string) ([]byte, error) {
+	start, end, err := u.find(data, key)
+	if err != nil {
+		return nil, err
+	}
+	lit, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, data[:start]...)
+	out = append(out, lit...)
+	return append(out, data[end:]...), nil
+}
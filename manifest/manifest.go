@@ -0,0 +1,174 @@
+// Package manifest reads and updates the version recorded in other
+// ecosystems' manifest files (package.json, Cargo.toml, pyproject.toml,
+// Chart.yaml, ...) while leaving the rest of the file untouched.
+package manifest
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrKeyNotFound the version key is not in the file.
+	ErrKeyNotFound = errors.New("version key not found")
+	// ErrDuplicateKey the version key is in the file more than once.
+	ErrDuplicateKey = errors.New("duplicate version key")
+	// ErrUnknownType the file type has no updater.
+	ErrUnknownType = errors.New("unknown manifest type")
+)
+
+// Updater reads and replaces the value of a key in one kind of file.
+type Updater interface {
+	// Read returns the current value of key.
+	Read(data []byte, key string) (string, error)
+	// Write returns data with the value of key set to value.
+	Write(data []byte, key, value string) ([]byte, error)
+}
+
+// updaters by type name
+var updaters = map[string]Updater{
+	"json": jsonUpdater{},
+	"toml": tomlUpdater{},
+	"yaml": yamlUpdater{},
+}
+
+// well known manifests, their type and version key
+var known = map[string][2]string{
+	"package.json":   {"json", "version"},
+	"composer.json":  {"json", "version"},
+	"Cargo.toml":     {"toml", "package.version"},
+	"pyproject.toml": {"toml", "project.version"},
+	"Chart.yaml":     {"yaml", "version"},
+}
+
+// Target is a manifest file and the key holding the version.
+type Target struct {
+	Path string
+	// Type is json, toml or yaml, it defaults from the file name.
+	Type string
+	// Key is the version key, dotted for toml tables (package.version).
+	Key string
+}
+
+// resolve fills in the type and key defaults.
+func (t Target) resolve() (Target, Updater, error) {
+	base := filepath.Base(t.Path)
+	if k, ok := known[base]; ok {
+		if len(t.Type) == 0 {
+			t.Type = k[0]
+		}
+		if len(t.Key) == 0 {
+			t.Key = k[1]
+		}
+	}
+	if len(t.Type) == 0 {
+		switch ext := strings.ToLower(filepath.Ext(base)); ext {
+		case ".json", ".toml", ".yaml":
+			t.Type = ext[1:]
+		case ".yml":
+			t.Type = "yaml"
+		}
+	}
+	if len(t.Key) == 0 {
+		t.Key = "version"
+	}
+	u, ok := updaters[t.Type]
+	if !ok {
+		return t, nil, fmt.Errorf("%s; %w %q", t.Path, ErrUnknownType, t.Type)
+	}
+	return t, u, nil
+}
+
+// Read returns the version currently in the target file.
+func (t Target) Read() (string, error) {
+	data, err := ioutil.ReadFile(t.Path)
+	if err != nil {
+		return "", err
+	}
+	return t.ReadData(data)
+}
+
+// ReadData returns the version in data, the target's contents.
+func (t Target) ReadData(data []byte) (string, error) {
+	t, u, err := t.resolve()
+	if err != nil {
+		return "", err
+	}
+	v, err := u.Read(data, t.Key)
+	if err != nil {
+		return "", fmt.Errorf("%s; %w", t.Path, err)
+	}
+	return v, nil
+}
+
+// Update returns data, the target's contents, with the version
+// replaced and the version it replaced.
+func (t Target) Update(data []byte, version string) ([]byte, string, error) {
+	t, u, err := t.resolve()
+	if err != nil {
+		return nil, "", err
+	}
+	old, err := u.Read(data, t.Key)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s; %w", t.Path, err)
+	}
+	if data, err = u.Write(data, t.Key, version); err != nil {
+		return nil, "", fmt.Errorf("%s; %w", t.Path, err)
+	}
+	return data, old, nil
+}
+
+// WriteFile replaces path with data by way of a temporary file in the
+// same directory, keeping the file mode.
+func WriteFile(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
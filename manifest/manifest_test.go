@@ -0,0 +1,162 @@
+package manifest
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		key  string
+		in   string
+		old  string
+		out  string
+		err  error
+	}{
+		// json
+		{name: "json", path: "package.json",
+			in:  `{"name": "api", "version": "1.0.0"}`,
+			old: "1.0.0", out: `{"name": "api", "version": "1.1.0"}`},
+		{name: "json nested", path: "package.json",
+			in:  `{"deps": {"version": "9.9.9"}, "files": ["version"], "version": "1.0.0"}`,
+			old: "1.0.0", out: `{"deps": {"version": "9.9.9"}, "files": ["version"], "version": "1.1.0"}`},
+		{name: "json only nested", path: "package.json",
+			in:  `{"deps": {"version": "9.9.9"}, "list": [{"version": "1"}]}`,
+			err: ErrKeyNotFound},
+		{name: "json duplicate", path: "package.json",
+			in:  `{"version": "1.0.0", "version": "2.0.0"}`,
+			err: ErrDuplicateKey},
+		{name: "json crlf", path: "package.json",
+			in:  "{\r\n  \"name\": \"api\",\r\n  \"version\": \"1.0.0\"\r\n}\r\n",
+			old: "1.0.0", out: "{\r\n  \"name\": \"api\",\r\n  \"version\": \"1.1.0\"\r\n}\r\n"},
+		{name: "json missing", path: "package.json",
+			in: `{"name": "api"}`, err: ErrKeyNotFound},
+		{name: "json other key", path: "app.json", key: "appVersion",
+			in:  `{"version": "1", "appVersion": "1.0.0"}`,
+			old: "1.0.0", out: `{"version": "1", "appVersion": "1.1.0"}`},
+
+		// toml
+		{name: "toml", path: "Cargo.toml",
+			in:  "[package]\nname = \"api\"\nversion = \"1.0.0\"\n",
+			old: "1.0.0", out: "[package]\nname = \"api\"\nversion = \"1.1.0\"\n"},
+		{name: "toml nested", path: "Cargo.toml",
+			in:  "version = \"0\"\n[dependencies.serde]\nversion = \"9.9\"\n[package]\nversion = '1.0.0' # ours\n[package.metadata]\nversion = \"8\"\n[[bin]]\nversion = \"7\"\n",
+			old: "1.0.0", out: "version = \"0\"\n[dependencies.serde]\nversion = \"9.9\"\n[package]\nversion = '1.1.0' # ours\n[package.metadata]\nversion = \"8\"\n[[bin]]\nversion = \"7\"\n"},
+		{name: "toml dotted table", path: "pyproject.toml",
+			in: "[ tool . poetry ]\nversion = \"1.0.0\"\n", key: "tool.poetry.version",
+			old: "1.0.0", out: "[ tool . poetry ]\nversion = \"1.1.0\"\n"},
+		{name: "toml duplicate", path: "Cargo.toml",
+			in:  "[package]\nversion = \"1.0.0\"\nversion = \"2.0.0\"\n",
+			err: ErrDuplicateKey},
+		{name: "toml same key other table", path: "Cargo.toml",
+			in:  "[package]\nversion = \"1.0.0\"\n[workspace.package]\nversion = \"2.0.0\"\n",
+			old: "1.0.0", out: "[package]\nversion = \"1.1.0\"\n[workspace.package]\nversion = \"2.0.0\"\n"},
+		{name: "toml crlf", path: "Cargo.toml",
+			in:  "[package]\r\nversion = \"1.0.0\"\r\nedition = \"2018\"\r\n",
+			old: "1.0.0", out: "[package]\r\nversion = \"1.1.0\"\r\nedition = \"2018\"\r\n"},
+		{name: "toml missing", path: "Cargo.toml",
+			in: "[dependencies]\nversion = \"1.0.0\"\n", err: ErrKeyNotFound},
+
+		// yaml
+		{name: "yaml", path: "Chart.yaml",
+			in:  "name: api\nversion: 1.0.0\n",
+			old: "1.0.0", out: "name: api\nversion: 1.1.0\n"},
+		{name: "yaml nested", path: "Chart.yaml",
+			in:  "dependencies:\n  - name: db\n    version: 9.9.9\nmeta:\n  version: 8\nversion: \"1.0.0\" # ours\n",
+			old: "1.0.0", out: "dependencies:\n  - name: db\n    version: 9.9.9\nmeta:\n  version: 8\nversion: \"1.1.0\" # ours\n"},
+		{name: "yaml duplicate", path: "Chart.yaml",
+			in:  "version: 1.0.0\nversion: 2.0.0\n",
+			err: ErrDuplicateKey},
+		{name: "yaml crlf", path: "Chart.yml",
+			in:  "name: api\r\nversion: '1.0.0'\r\n",
+			old: "1.0.0", out: "name: api\r\nversion: '1.1.0'\r\n"},
+		{name: "yaml missing", path: "Chart.yaml",
+			in: "name: api\n  version: 1.0.0\n", err: ErrKeyNotFound},
+		{name: "yaml other key", path: "Chart.yaml", key: "appVersion",
+			in:  "version: 0.1.0\nappVersion: 1.0.0\n",
+			old: "1.0.0", out: "version: 0.1.0\nappVersion: 1.1.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tgt := Target{Path: tt.path, Key: tt.key}
+			out, old, err := tgt.Update([]byte(tt.in), "1.1.0")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Update error %v, want %v", err, tt.err)
+			}
+			if _, rerr := tgt.ReadData([]byte(tt.in)); !errors.Is(rerr, tt.err) {
+				t.Errorf("ReadData error %v, want %v", rerr, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if old != tt.old || string(out) != tt.out {
+				t.Errorf("Update = %q, %q\nwant %q, %q", out, old, tt.out, tt.old)
+			}
+			if v, err := tgt.ReadData(out); err != nil || v != "1.1.0" {
+				t.Errorf("ReadData after Update = %q, %v", v, err)
+			}
+		})
+	}
+}
+
+func TestUpdateQuoting(t *testing.T) {
+	tests := []struct {
+		name, path, in, version, out string
+		fails                        bool
+	}{
+		{name: "json escapes", path: "package.json", in: `{"version": "1"}`, version: `1.0.0-"x"`, out: `{"version": "1.0.0-\"x\""}`},
+		{name: "yaml number", path: "Chart.yaml", in: "version: 1\n", version: "2.0", out: "version: \"2.0\"\n"},
+		{name: "yaml single", path: "Chart.yaml", in: "version: 'v1'\n", version: "v1'2", out: "version: 'v1''2'\n"},
+		{name: "yaml double", path: "Chart.yaml", in: "version: \"1\"\n", version: `1"2\3`, out: "version: \"1\\\"2\\\\3\"\n"},
+		{name: "toml quote", path: "Cargo.toml", in: "[package]\nversion = \"1\"\n", version: `1"2`, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tgt := Target{Path: tt.path}
+			out, _, err := tgt.Update([]byte(tt.in), tt.version)
+			if tt.fails {
+				if err == nil {
+					t.Errorf("Update = %q, want an error", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.out {
+				t.Errorf("Update = %q, want %q", out, tt.out)
+			}
+			if v, err := tgt.ReadData(out); err != nil || v != tt.version {
+				t.Errorf("ReadData after Update = %q, %v, want %q", v, err, tt.version)
+			}
+		})
+	}
+}
+
+func TestUnknownType(t *testing.T) {
+	if _, err := (Target{Path: "setup.cfg"}).ReadData([]byte("version = 1\n")); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("ReadData error %v, want ErrUnknownType", err)
+	}
+}
@@ -0,0 +1,106 @@
+package manifest
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tomlUpdater handles a string key in a toml table, the key is the
+// table and key name joined by a dot (package.version); a key without
+// a dot is at the top level.
+type tomlUpdater struct{}
+
+var (
+	tomlTable      = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9_.\-"' ]+?)\s*\]\s*(#.*)?$`)
+	tomlArrayTable = regexp.MustCompile(`^\s*\[\[.*\]\]\s*(#.*)?$`)
+)
+
+// find returns the line index and match of the key in its table, the
+// key must be there once.
+func (tomlUpdater) find(lines [][]byte, key string) (int, [][]byte, error) {
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+	re := regexp.MustCompile(`^(\s*"?` + regexp.QuoteMeta(name) + `"?\s*=\s*)("[^"]*"|'[^']*')(.*)$`)
+	cur := ""
+	at, match := -1, [][]byte(nil)
+	for i, l := range lines {
+		l = bytes.TrimRight(l, "\r")
+		if m := tomlTable.FindSubmatch(l); m != nil {
+			cur = strings.Join(strings.Fields(strings.Replace(string(m[1]), ".", " . ", -1)), "")
+			continue
+		}
+		if tomlArrayTable.Match(l) {
+			// array tables ([[bin]]) never hold the key we want
+			cur = "[["
+			continue
+		}
+		if cur != table {
+			continue
+		}
+		if m := re.FindSubmatch(l); m != nil {
+			if at >= 0 {
+				return 0, nil, fmt.Errorf("%s; %w", key, ErrDuplicateKey)
+			}
+			at, match = i, m
+		}
+	}
+	if at < 0 {
+		return 0, nil, fmt.Errorf("%s; %w", key, ErrKeyNotFound)
+	}
+	return at, match, nil
+}
+
+func (u tomlUpdater) Read(data []byte, key string) (string, error) {
+	_, m, err := u.find(bytes.Split(data, []byte("\n")), key)
+	if err != nil {
+		return "", err
+	}
+	return string(m[2][1 : len(m[2])-1]), nil
+}
+
+func (u tomlUpdater) Write(data []byte, key, value string) ([]byte, error) {
+	lines := bytes.Split(data, []byte("\n"))
+	i, m, err := u.find(lines, key)
+	if err != nil {
+		return nil, err
+	}
+	q := m[2][0]
+	if strings.ContainsRune(value, rune(q)) || strings.ContainsRune(value, '\\') {
+		return nil, fmt.Errorf("%s; can't quote %q", key, value)
+	}
+	var l []byte
+	l = append(l, m[1]...)
+	l = append(l, q)
+	l = append(l, value...)
+	l = append(l, q)
+	l = append(l, m[3]...)
+	if bytes.HasSuffix(lines[i], []byte("\r")) {
+		l = append(l, '\r')
+	}
+	lines[i] = l
+	return bytes.Join(lines, []byte("\n")), nil
+}
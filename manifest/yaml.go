@@ -0,0 +1,98 @@
+package manifest
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// yamlUpdater handles a top level scalar key in a yaml document.
+type yamlUpdater struct{}
+
+// find returns the line index and match of the top level key, which
+// must be there once.
+func (yamlUpdater) find(lines [][]byte, key string) (int, [][]byte, error) {
+	re := regexp.MustCompile(`^(` + regexp.QuoteMeta(key) + `\s*:\s*)("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s#"'][^#]*?)?(\s*(#.*)?)$`)
+	at, match := -1, [][]byte(nil)
+	for i, l := range lines {
+		l = bytes.TrimRight(l, "\r")
+		if m := re.FindSubmatch(l); m != nil {
+			if at >= 0 {
+				return 0, nil, fmt.Errorf("%s; %w", key, ErrDuplicateKey)
+			}
+			at, match = i, m
+		}
+	}
+	if at < 0 {
+		return 0, nil, fmt.Errorf("%s; %w", key, ErrKeyNotFound)
+	}
+	return at, match, nil
+}
+
+func (u yamlUpdater) Read(data []byte, key string) (string, error) {
+	_, m, err := u.find(bytes.Split(data, []byte("\n")), key)
+	if err != nil {
+		return "", err
+	}
+	// undo the escapes Write uses for quoted scalars
+	v := string(m[2])
+	switch {
+	case len(v) >= 2 && v[0] == '\'':
+		v = strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	case len(v) >= 2 && v[0] == '"':
+		v = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(v[1 : len(v)-1])
+	}
+	return v, nil
+}
+
+func (u yamlUpdater) Write(data []byte, key, value string) ([]byte, error) {
+	lines := bytes.Split(data, []byte("\n"))
+	i, m, err := u.find(lines, key)
+	if err != nil {
+		return nil, err
+	}
+	// keep the quoting style, plain scalars that would be read back as
+	// something else (1.0, a suffix with ": ") get double quotes
+	v := value
+	switch {
+	case len(m[2]) > 0 && m[2][0] == '\'':
+		v = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case len(m[2]) > 0 && m[2][0] == '"', !yamlPlain.MatchString(value):
+		v = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	var l []byte
+	l = append(l, m[1]...)
+	l = append(l, v...)
+	l = append(l, m[3]...)
+	if bytes.HasSuffix(lines[i], []byte("\r")) {
+		l = append(l, '\r')
+	}
+	lines[i] = l
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// yamlPlain matches versions that are safe as plain scalars, they
+// start with a letter or have at least two dots so they can't be read
+// as a number.
+var yamlPlain = regexp.MustCompile(`^([A-Za-z][0-9A-Za-z._+-]*|[0-9]+\.[0-9]+\.[0-9A-Za-z._+-]*)$`)
@@ -0,0 +1,265 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cascade maps the bump of an entry onto the bump the entries that
+// depend on it get, "" or "none" being none.
+type Cascade map[string]string
+
+// DefaultCascade gives the dependents of an entry a patch bump when it
+// gets a major or minor one.
+var DefaultCascade = Cascade{"major": "patch", "minor": "patch"}
+
+// bumpRank orders the bump levels.
+var bumpRank = map[string]int{"": 0, "none": 0, "patch": 1, "minor": 2, "major": 3}
+
+// BumpRank orders the bump levels: 0 for none ("" or "none"), then 1,
+// 2 and 3 for patch, minor and major; ok is false for anything else.
+func BumpRank(level string) (rank int, ok bool) {
+	rank, ok = bumpRank[level]
+	return rank, ok
+}
+
+// Bumped is an entry changed by BumpCascade.
+type Bumped struct {
+	Name  string
+	Level string
+	Old   Vers
+	New   Vers
+}
+
+// Depend records that name depends on deps, refusing dependencies on
+// entries that do not exist and ones that make a cycle.
+func (f *VFile) Depend(name string, deps ...string) error {
+	if _, ok := f.Version[name]; !ok {
+		return entryErr(name, ErrEntryNotFound)
+	}
+	cur := append([]string(nil), f.Deps[name]...)
+	for _, d := range deps {
+		if _, ok := f.Version[d]; !ok {
+			return entryErr(d, ErrEntryNotFound)
+		}
+		if !contains(cur, d) {
+			cur = append(cur, d)
+		}
+	}
+	sort.Strings(cur)
+	if f.Deps == nil {
+		f.Deps = make(Deps)
+	}
+	old, had := f.Deps[name]
+	f.Deps[name] = cur
+	if c := f.cycle(); c != nil {
+		if had {
+			f.Deps[name] = old
+		} else {
+			delete(f.Deps, name)
+		}
+		return entryErr(name, fmt.Errorf("%w (%s)", ErrCycle, strings.Join(c, " -> ")))
+	}
+	return nil
+}
+
+// Undepend drops the dependencies of name on deps.
+func (f *VFile) Undepend(name string, deps ...string) error {
+	if _, ok := f.Version[name]; !ok {
+		return entryErr(name, ErrEntryNotFound)
+	}
+	var keep []string
+	for _, d := range f.Deps[name] {
+		if !contains(deps, d) {
+			keep = append(keep, d)
+		}
+	}
+	if len(keep) == 0 {
+		delete(f.Deps, name)
+	} else {
+		f.Deps[name] = keep
+	}
+	return nil
+}
+
+// DependsOn returns the entries name depends on directly.
+func (f *VFile) DependsOn(name string) []string {
+	return append([]string(nil), f.Deps[name]...)
+}
+
+// Dependents returns the entries that depend directly on name.
+func (f *VFile) Dependents(name string) []string {
+	var out []string
+	for e, deps := range f.Deps {
+		if contains(deps, name) {
+			out = append(out, e)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cycle returns a dependency cycle (its first entry repeated at the
+// end), nil if there is none.
+func (f *VFile) cycle() []string {
+	const (
+		unseen = iota
+		active
+		done
+	)
+	state := make(map[string]int)
+	var path, found []string
+	var visit func(string) bool
+	visit = func(n string) bool {
+		state[n] = active
+		path = append(path, n)
+		for _, d := range f.Deps[n] {
+			switch state[d] {
+			case active:
+				for i, p := range path {
+					if p == d {
+						found = append(append([]string(nil), path[i:]...), d)
+					}
+				}
+				return true
+			case unseen:
+				if visit(d) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = done
+		return false
+	}
+	for _, n := range f.Version.Names() {
+		if state[n] == unseen && visit(n) {
+			return found
+		}
+	}
+	return nil
+}
+
+// Order returns the entries with every entry after those it depends
+// on, otherwise by name.
+func (f *VFile) Order() ([]string, error) {
+	if c := f.cycle(); c != nil {
+		return nil, fmt.Errorf("%s; %w", strings.Join(c, " -> "), ErrCycle)
+	}
+	var out []string
+	seen := make(map[string]bool)
+	var visit func(string)
+	visit = func(n string) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, d := range f.Deps[n] {
+			visit(d)
+		}
+		out = append(out, n)
+	}
+	for _, n := range f.Version.Names() {
+		visit(n)
+	}
+	return out, nil
+}
+
+// BumpCascade bumps the entries name stands for (see Resolve) and, as
+// the policy c says, the entries that depend on them directly or not.
+// An entry depending on several bumped entries gets the largest of
+// their bumps, and the members of a group all get the largest bump of
+// any of them.  The bumped entries are returned with every entry after
+// those it depends on.
+func (f *VFile) BumpCascade(name, what string, c Cascade) ([]Bumped, error) {
+	start, err := f.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := bumpRank[what]; !ok || bumpRank[what] == 0 {
+		return nil, fmt.Errorf("%q; %w", what, ErrInvalidBump)
+	}
+	order, err := f.Order()
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[string]string)
+	raise := func(n, level string) bool {
+		if bumpRank[level] > bumpRank[levels[n]] {
+			levels[n] = level
+			return true
+		}
+		return false
+	}
+	for _, n := range start {
+		levels[n] = what
+	}
+	// the levels only go up, so this settles
+	for changed := true; changed; {
+		changed = false
+		for _, n := range order {
+			for _, d := range f.Deps[n] {
+				if l, ok := levels[d]; ok && raise(n, c[l]) {
+					changed = true
+				}
+			}
+		}
+		for _, members := range f.Groups {
+			var level string
+			for _, m := range members {
+				if bumpRank[levels[m]] > bumpRank[level] {
+					level = levels[m]
+				}
+			}
+			for _, m := range members {
+				if bumpRank[level] != 0 && raise(m, level) {
+					changed = true
+				}
+			}
+		}
+	}
+	var out []Bumped
+	for _, n := range order {
+		level, ok := levels[n]
+		if !ok {
+			continue
+		}
+		old := *f.Version[n]
+		nv, err := f.Bump(n, level)
+		if err != nil {
+			return nil, entryErr(n, err)
+		}
+		out = append(out, Bumped{Name: n, Level: level, Old: old, New: nv})
+	}
+	return out, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
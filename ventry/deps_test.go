@@ -0,0 +1,196 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testVFile returns a VFile with the entries at v1.0.0 and the
+// dependencies given as name: deps.
+func testVFile(t *testing.T, names []string, deps map[string][]string) *VFile {
+	t.Helper()
+	f := newVFile(1)
+	for _, n := range names {
+		f.Set(n, Vers{Prefix: "v", Major: 1})
+	}
+	for n, d := range deps {
+		if err := f.Depend(n, d...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+// levels returns the bump each entry got.
+func levels(bumped []Bumped) map[string]string {
+	out := make(map[string]string)
+	for _, b := range bumped {
+		out[b.Name] = b.Level
+	}
+	return out
+}
+
+func TestBumpRank(t *testing.T) {
+	for _, tt := range []struct {
+		level string
+		rank  int
+		ok    bool
+	}{
+		{"", 0, true}, {"none", 0, true}, {"patch", 1, true}, {"minor", 2, true}, {"major", 3, true}, {"huge", 0, false},
+	} {
+		if rank, ok := BumpRank(tt.level); rank != tt.rank || ok != tt.ok {
+			t.Errorf("BumpRank(%q) = %d, %v, want %d, %v", tt.level, rank, ok, tt.rank, tt.ok)
+		}
+	}
+}
+
+func TestDependCycle(t *testing.T) {
+	f := testVFile(t, []string{"a", "b", "c"}, map[string][]string{"b": {"a"}, "c": {"b"}})
+	for _, tt := range []struct{ name, dep string }{{"a", "c"}, {"a", "a"}, {"b", "c"}} {
+		if err := f.Depend(tt.name, tt.dep); !errors.Is(err, ErrCycle) {
+			t.Errorf("Depend(%s, %s) = %v, want ErrCycle", tt.name, tt.dep, err)
+		}
+	}
+	if err := f.Depend("a", "missing"); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Depend on a missing entry: %v, want ErrEntryNotFound", err)
+	}
+	// a cycle edited into the file is refused rather than looped on
+	f.Deps["a"] = []string{"c"}
+	if _, err := f.BumpCascade("a", "major", DefaultCascade); !errors.Is(err, ErrCycle) {
+		t.Errorf("BumpCascade with a cycle: %v, want ErrCycle", err)
+	}
+	if f.Version["a"].Major != 1 {
+		t.Errorf("BumpCascade with a cycle bumped a")
+	}
+}
+
+func TestBumpCascadeDiamond(t *testing.T) {
+	// top depends on left and right, which both depend on base, and
+	// side depends on base and left
+	f := testVFile(t, []string{"base", "left", "right", "top", "side", "other"}, map[string][]string{
+		"left":  {"base"},
+		"right": {"base"},
+		"top":   {"left", "right"},
+		"side":  {"base", "left"},
+	})
+	bumped, err := f.BumpCascade("base", "major", Cascade{"major": "minor", "minor": "patch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"base": "major", "left": "minor", "right": "minor", "top": "patch", "side": "minor"}
+	if got := levels(bumped); !reflect.DeepEqual(got, want) {
+		t.Errorf("levels %v, want %v", got, want)
+	}
+	// each entry comes after those it depends on
+	pos := make(map[string]int)
+	for i, b := range bumped {
+		pos[b.Name] = i
+	}
+	for n, deps := range f.Deps {
+		for _, d := range deps {
+			if pos[n] < pos[d] {
+				t.Errorf("%s is bumped before %s, which it depends on", n, d)
+			}
+		}
+	}
+	for _, b := range bumped {
+		if nv := f.Version[b.Name]; nv.String() != b.New.String() || b.Old.String() != "v1.0.0" {
+			t.Errorf("%s: %s to %s, the file has %s", b.Name, b.Old, b.New, nv)
+		}
+	}
+	if f.Version["base"].String() != "v2.0.0" || f.Version["top"].String() != "v1.0.1" {
+		t.Errorf("base %s, top %s, want v2.0.0 and v1.0.1", f.Version["base"], f.Version["top"])
+	}
+}
+
+func TestBumpCascadePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		what   string
+		policy Cascade
+		want   map[string]string
+	}{
+		// app's patch does not cascade to cli
+		{"default major", "major", DefaultCascade, map[string]string{"lib": "major", "app": "patch"}},
+		{"default minor", "minor", DefaultCascade, map[string]string{"lib": "minor", "app": "patch"}},
+		{"default patch", "patch", DefaultCascade, map[string]string{"lib": "patch"}},
+		{"patch to patch", "patch", Cascade{"patch": "patch"}, map[string]string{"lib": "patch", "app": "patch", "cli": "patch"}},
+		{"major to major", "major", Cascade{"major": "major", "minor": "patch"},
+			map[string]string{"lib": "major", "app": "major", "cli": "major"}},
+		{"none", "major", Cascade{"major": "none"}, map[string]string{"lib": "major"}},
+		{"no cascade", "major", Cascade{}, map[string]string{"lib": "major"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// cli depends on app, which depends on lib
+			f := testVFile(t, []string{"lib", "app", "cli", "doc"}, map[string][]string{"app": {"lib"}, "cli": {"app"}})
+			bumped, err := f.BumpCascade("lib", tt.what, tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := levels(bumped); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("levels %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBumpCascadeErrors(t *testing.T) {
+	f := testVFile(t, []string{"a"}, nil)
+	if _, err := f.BumpCascade("b", "minor", DefaultCascade); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("missing entry: %v, want ErrEntryNotFound", err)
+	}
+	for _, what := range []string{"huge", "none", ""} {
+		if _, err := f.BumpCascade("a", what, DefaultCascade); !errors.Is(err, ErrInvalidBump) {
+			t.Errorf("bump %q: %v, want ErrInvalidBump", what, err)
+		}
+	}
+}
+
+func TestBumpCascadeGroups(t *testing.T) {
+	// app depends on lib and is in a group with cli
+	f := testVFile(t, []string{"lib", "app", "cli", "tool"}, map[string][]string{"app": {"lib"}, "tool": {"cli"}})
+	if err := f.Group("g", "app", "cli"); err != nil {
+		t.Fatal(err)
+	}
+	bumped, err := f.BumpCascade("lib", "minor", Cascade{"minor": "patch", "patch": "patch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"lib": "minor", "app": "patch", "cli": "patch", "tool": "patch"}
+	if got := levels(bumped); !reflect.DeepEqual(got, want) {
+		t.Errorf("levels %v, want %v", got, want)
+	}
+	if err := f.CheckGroups(); err != nil {
+		t.Error(err)
+	}
+
+	bumped, err = f.BumpCascade("@g", "major", Cascade{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := levels(bumped); !reflect.DeepEqual(got, map[string]string{"app": "major", "cli": "major"}) {
+		t.Errorf("bump @g levels %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// DefaultEnvName is the default template for variable names.
+const DefaultEnvName = "{{.Name}}_VERS"
+
+// EnvOptions control how entries are turned into environment variables.
+type EnvOptions struct {
+	// Name is a template (see TemplateData) for the variable name, it
+	// defaults to DefaultEnvName.  The result is upper cased and any
+	// character that can't be in a variable name becomes an underscore.
+	Name string
+	// Components adds <NAME>_MAJOR, <NAME>_MINOR and <NAME>_PATCH.
+	Components bool
+}
+
+// EnvVar is a single environment variable.
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// env dialects and the format names that select them
+var envDialects = map[string]string{
+	"shell":      "sh",
+	"sh":         "sh",
+	"bash":       "sh",
+	"zsh":        "sh",
+	"fish":       "fish",
+	"powershell": "powershell",
+	"pwsh":       "powershell",
+	"ps":         "powershell",
+	"cmd":        "cmd", // batch files
+	"bat":        "cmd",
+	"dotenv":     "dotenv",
+	"env":        "dotenv",
+}
+
+// IsEnvFormat reports whether format is one of the environment
+// variable formats (sh, bash, fish, powershell, cmd or dotenv).
+func IsEnvFormat(format string) bool {
+	_, ok := envDialects[format]
+	return ok
+}
+
+var badEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// EnvName turns s into a valid variable name (API-V2 -> API_V2).
+func EnvName(s string) string {
+	s = badEnvChars.ReplaceAllString(strings.ToUpper(s), "_")
+	if len(s) == 0 || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// EnvVars returns the variables for an entry.
+func EnvVars(name string, ve Vers, opts EnvOptions) ([]EnvVar, error) {
+	tmpl := opts.Name
+	if len(tmpl) == 0 {
+		tmpl = DefaultEnvName
+	}
+	t, err := NewTemplate("env-name", tmpl)
+	if err != nil {
+		return nil, err
+	}
+	n, err := ExecTemplate(t, name, ve)
+	if err != nil {
+		return nil, err
+	}
+	n = EnvName(n)
+	vars := []EnvVar{{Name: n, Value: ve.String()}}
+	if opts.Components {
+		vars = append(vars,
+			EnvVar{Name: n + "_MAJOR", Value: fmt.Sprint(ve.Major)},
+			EnvVar{Name: n + "_MINOR", Value: fmt.Sprint(ve.Minor)},
+			EnvVar{Name: n + "_PATCH", Value: fmt.Sprint(ve.Patch)})
+	}
+	return vars, nil
+}
+
+// envLine renders a single variable in the given dialect, quoting the
+// value so it is taken literally.
+func envLine(dialect string, ev EnvVar) string {
+	switch dialect {
+	case "fish":
+		r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		return fmt.Sprintf("set -gx %s '%s';", ev.Name, r.Replace(ev.Value))
+	case "powershell":
+		return fmt.Sprintf("$env:%s = '%s'", ev.Name, strings.ReplaceAll(ev.Value, "'", "''"))
+	case "cmd":
+		// the lines are for a batch file, where % is doubled (at the
+		// prompt it would not be); cmd has no way to escape a double
+		// quote or a line break, drop them
+		r := strings.NewReplacer(`"`, "", "\r", "", "\n", "", "%", "%%")
+		return fmt.Sprintf(`set "%s=%s"`, ev.Name, r.Replace(ev.Value))
+	case "dotenv":
+		r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`)
+		return fmt.Sprintf(`%s="%s"`, ev.Name, r.Replace(ev.Value))
+	}
+	return fmt.Sprintf("export %s='%s'", ev.Name, strings.ReplaceAll(ev.Value, "'", `'\''`))
+}
+
+// renderEnv writes an entry's variables to w in an env format.
+func renderEnv(w io.Writer, format, name string, ve Vers, opts EnvOptions) error {
+	vars, err := EnvVars(name, ve, opts)
+	if err != nil {
+		return err
+	}
+	for _, ev := range vars {
+		if _, err := fmt.Fprintln(w, envLine(envDialects[format], ev)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestEnvLine(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string]string
+	}{
+		{"a'b", map[string]string{
+			"sh":         `export X='a'\''b'`,
+			"fish":       `set -gx X 'a\'b';`,
+			"powershell": `$env:X = 'a''b'`,
+			"cmd":        `set "X=a'b"`,
+			"dotenv":     `X="a'b"`,
+		}},
+		{`a"b`, map[string]string{
+			"sh":         `export X='a"b'`,
+			"fish":       `set -gx X 'a"b';`,
+			"powershell": `$env:X = 'a"b'`,
+			"cmd":        `set "X=ab"`,
+			"dotenv":     `X="a\"b"`,
+		}},
+		{"$HOME", map[string]string{
+			"sh":         `export X='$HOME'`,
+			"fish":       `set -gx X '$HOME';`,
+			"powershell": `$env:X = '$HOME'`,
+			"cmd":        `set "X=$HOME"`,
+			"dotenv":     `X="\$HOME"`,
+		}},
+		{"50%", map[string]string{
+			"sh":         `export X='50%'`,
+			"fish":       `set -gx X '50%';`,
+			"powershell": `$env:X = '50%'`,
+			"cmd":        `set "X=50%%"`,
+			"dotenv":     `X="50%"`,
+		}},
+		{`a\b`, map[string]string{
+			"sh":         `export X='a\b'`,
+			"fish":       `set -gx X 'a\\b';`,
+			"powershell": `$env:X = 'a\b'`,
+			"cmd":        `set "X=a\b"`,
+			"dotenv":     `X="a\\b"`,
+		}},
+		{"a\nb", map[string]string{
+			"sh":         "export X='a\nb'",
+			"fish":       "set -gx X 'a\nb';",
+			"powershell": "$env:X = 'a\nb'",
+			"cmd":        `set "X=ab"`,
+			"dotenv":     `X="a\nb"`,
+		}},
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		sh = ""
+	}
+	for _, tt := range tests {
+		for dialect, want := range tt.want {
+			if got := envLine(dialect, EnvVar{Name: "X", Value: tt.value}); got != want {
+				t.Errorf("%s %q: %s, want %s", dialect, tt.value, got, want)
+			}
+		}
+		if len(sh) == 0 {
+			continue
+		}
+		// the shell gets the value back unchanged
+		out, err := exec.Command(sh, "-c", envLine("sh", EnvVar{Name: "X", Value: tt.value})+`; printf %s "$X"`).Output()
+		if err != nil || string(out) != tt.value {
+			t.Errorf("sh %q: got back %q, %v", tt.value, out, err)
+		}
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	ve := Vers{Prefix: "v", Major: 1, Minor: 2, Patch: 3}
+	tests := []struct {
+		name string
+		opts EnvOptions
+		want []EnvVar
+	}{
+		{"api", EnvOptions{}, []EnvVar{{"API_VERS", "v1.2.3"}}},
+		{"my-api.v2", EnvOptions{}, []EnvVar{{"MY_API_V2_VERS", "v1.2.3"}}},
+		{"api", EnvOptions{Name: "{{.Major}}x"}, []EnvVar{{"_1X", "v1.2.3"}}},
+		{"api", EnvOptions{Name: "APP", Components: true}, []EnvVar{
+			{"APP", "v1.2.3"}, {"APP_MAJOR", "1"}, {"APP_MINOR", "2"}, {"APP_PATCH", "3"}}},
+	}
+	for _, tt := range tests {
+		got, err := EnvVars(tt.name, ve, tt.opts)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("EnvVars(%s, %+v) = %v, %v, want %v", tt.name, tt.opts, got, err, tt.want)
+		}
+	}
+}
+
+func TestRenderEnvFormats(t *testing.T) {
+	ents := Entries{"api": &Vers{Prefix: "v", Major: 1}, "web": &Vers{Major: 2}}
+	for format, want := range map[string]string{
+		"bash": "export API_VERS='v1.0.0'\nexport WEB_VERS='2.0.0'\n",
+		"bat":  "set \"API_VERS=v1.0.0\"\nset \"WEB_VERS=2.0.0\"\n",
+		"env":  "API_VERS=\"v1.0.0\"\nWEB_VERS=\"2.0.0\"\n",
+		"pwsh": "$env:API_VERS = 'v1.0.0'\n$env:WEB_VERS = '2.0.0'\n",
+	} {
+		var buf bytes.Buffer
+		if err := RenderAll(&buf, ents, format); err != nil || buf.String() != want {
+			t.Errorf("%s: %q, %v, want %q", format, buf.String(), err, want)
+		}
+	}
+}
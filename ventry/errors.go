@@ -0,0 +1,70 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "errors"
+
+// Errors returned by this package, they are usually wrapped (see
+// EntryError) so test for them with errors.Is.
+var (
+	// ErrEntryNotFound the named entry is not in the store.
+	ErrEntryNotFound = errors.New("does not exist")
+	// ErrNoHistory the entry has no previous value to restore.
+	ErrNoHistory = errors.New("previous value does not exist")
+	// ErrLockTimeout the version file lock could not be obtained.
+	ErrLockTimeout = errors.New("did not obtain lock")
+	// ErrUnsupportedFormat unknown file type or output format.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrInvalidBump the bump level is not major, minor or patch.
+	ErrInvalidBump = errors.New("invalid bump setting")
+	// ErrInvalidVersion the version does not fit the versioning scheme.
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrCycle a dependency would make entries depend on themselves.
+	ErrCycle = errors.New("dependency cycle")
+	// ErrHasDependents other entries depend on the entry.
+	ErrHasDependents = errors.New("other entries depend on it")
+	// ErrDiverged the members of a group do not share one version.
+	ErrDiverged = errors.New("group members have diverged")
+	// ErrInGroup the entry is a member of another group.
+	ErrInGroup = errors.New("already in a group")
+	// ErrExists the entry to rename or copy to is in the store.
+	ErrExists = errors.New("already exists")
+)
+
+// EntryError records the entry an error happened on.
+type EntryError struct {
+	Name string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return e.Name + "; " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// entryErr wraps err with the entry name.
+func entryErr(name string, err error) error {
+	return &EntryError{Name: name, Err: err}
+}
@@ -0,0 +1,124 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofrs/flock"
+)
+
+func TestErrEntryNotFound(t *testing.T) {
+	f := newVFile(1)
+	f.Set("api", Vers{Prefix: "v", Major: 1})
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"Get", func() error { _, err := f.Get("web"); return err }()},
+		{"Remove", f.Remove("web")},
+		{"Bump", func() error { _, err := f.Bump("web", "patch"); return err }()},
+		{"Undo", func() error { _, err := f.Undo("web"); return err }()},
+		{"Render", Render(ioutil.Discard, f.Version, "web", "json")},
+	}
+	for _, tt := range tests {
+		var ee *EntryError
+		if !errors.Is(tt.err, ErrEntryNotFound) {
+			t.Errorf("%s: %v, want ErrEntryNotFound", tt.name, tt.err)
+		} else if !errors.As(tt.err, &ee) || ee.Name != "web" {
+			t.Errorf("%s: %v, want an EntryError for web", tt.name, tt.err)
+		}
+	}
+}
+
+func TestErrNoHistory(t *testing.T) {
+	f := newVFile(1)
+	f.Set("api", Vers{Prefix: "v", Major: 1})
+	if _, err := f.Undo("api"); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Undo without history: %v, want ErrNoHistory", err)
+	}
+	f.Set("api", Vers{Prefix: "v", Major: 2})
+	if _, err := f.Undo("api"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Undo("api"); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("second Undo: %v, want ErrNoHistory", err)
+	}
+}
+
+func TestErrLockTimeout(t *testing.T) {
+	v, done := fileStore(t, 1)
+	defer done()
+	other := flock.New(v.LPath())
+	if ok, err := other.TryLock(); !ok || err != nil {
+		t.Fatalf("lock %s: %v, %v", v.LPath(), ok, err)
+	}
+	if err := v.Read(1); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Read under a lock: %v, want ErrLockTimeout", err)
+	}
+	if err := v.Write(1); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Write under a lock: %v, want ErrLockTimeout", err)
+	}
+	other.Unlock()
+	if err := v.Read(1); err != nil {
+		t.Errorf("Read after the unlock: %v", err)
+	}
+}
+
+func TestErrUnsupportedFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ventry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "v.txt")
+	if err := ioutil.WriteFile(path, []byte("api: v1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	ents := Entries{"api": &Vers{Prefix: "v", Major: 1}}
+	var buf bytes.Buffer
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"Read", v.Read(1)},
+		{"Write", v.Write(1)},
+		{"Decode", func() error { _, err := Decode([]byte("x"), ".ini"); return err }()},
+		{"RenderAll", RenderAll(&buf, ents, "xml")},
+		{"Render", Render(&buf, ents, "api", "xml")},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrUnsupportedFormat) {
+			t.Errorf("%s: %v, want ErrUnsupportedFormat", tt.name, tt.err)
+		}
+	}
+}
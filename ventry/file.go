@@ -22,12 +22,11 @@ package ventry
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/apex/log"
@@ -37,6 +36,8 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+var _ Store = (*VEntry)(nil)
+
 // writeVersionFile updates the version file info
 func writeVersionFile(path string, info *VFile) error {
 	var bytes []byte
@@ -63,7 +64,7 @@ func writeVersionFile(path string, info *VFile) error {
 		}
 
 	default:
-		return fmt.Errorf("unsupported file type")
+		return fmt.Errorf("%q; %w", ext, ErrUnsupportedFormat)
 	}
 	err = ioutil.WriteFile(p, bytes, 0640)
 	if err != nil {
@@ -74,8 +75,6 @@ func writeVersionFile(path string, info *VFile) error {
 
 // readVersionFile gets the version file info
 func readVersionFile(path string) (*VFile, error) {
-	var info VFile
-
 	p, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
@@ -84,23 +83,43 @@ func readVersionFile(path string) (*VFile, error) {
 	if err != nil {
 		return nil, err
 	}
-	// get the type we can handle json or yaml
-	switch ext := filepath.Ext(p); ext {
-	case ".json":
-		if err := json.Unmarshal([]byte(data), &info); err != nil {
-			return nil, err
-		}
+	return Decode(data, filepath.Ext(p))
+}
 
-	case ".yml":
-		fallthrough
-	case ".yaml":
-		if err := yaml.Unmarshal([]byte(data), &info); err != nil {
-			return nil, err
-		}
+// Decode parses the contents of a version file, ext (".json", ".yaml"
+// or ".yml") selects the format.  An empty file has no entries.
+func Decode(data []byte, ext string) (*VFile, error) {
+	var info VFile
 
-	default:
-		return nil, fmt.Errorf("unsupported file type")
+	if len(data) != 0 {
+		// get the type we can handle json or yaml
+		switch ext {
+		case ".json":
+			if err := json.Unmarshal(data, &info); err != nil {
+				return nil, err
+			}
+
+		case ".yml":
+			fallthrough
+		case ".yaml":
+			if err := yaml.Unmarshal(data, &info); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("%q; %w", ext, ErrUnsupportedFormat)
+		}
+	}
+	if info.Version == nil {
+		info.Version = make(Entries)
 	}
+	if info.Prev == nil {
+		info.Prev = make(Rollback)
+	}
+	if info.History == nil {
+		info.History = make(History)
+	}
+	info.SetHistory(1)
 	return &info, nil
 }
 
@@ -124,10 +143,8 @@ func Open(path string, creat bool) (*VEntry, error) {
 	defer f.Close()
 	ve.path = p
 	ve.lck = flock.New(p + ".lck")
-	ve.ent = &VFile{
-		Version: make(Entries),
-		Prev:    make(Rollback),
-	}
+	ve.keep = 1
+	ve.ent = newVFile(ve.keep)
 	log.Debugf("Open(): path->%s VEntry->%+#v", path, ve)
 	return &ve, nil
 }
@@ -142,229 +159,208 @@ func (v *VEntry) LPath() string {
 	return v.lck.Path()
 }
 
+// SetHistory sets how many previous values are kept per entry
+// (anything less than one keeps a single value).
+func (v *VEntry) SetHistory(keep int) {
+	if keep < 1 {
+		keep = 1
+	}
+	v.keep = keep
+	v.ent.SetHistory(keep)
+}
+
 // Add will update/add an entry
 func (v *VEntry) Add(name string, ent *Vers) {
 	log.Debugf("Add(): enrty->%s values->%+#v", name, ent)
-	// push current values to history
-	if ve, ok := v.ent.Version[name]; ok {
-		v.ent.Prev[name] = *ve
-	}
-	v.ent.Version[name] = ent
+	v.ent.Set(name, *ent)
 }
 
 // Rm will remove an entry
 func (v *VEntry) Rm(name string) {
-	if _, ok := v.ent.Version[name]; ok {
-		delete(v.ent.Version, name)
-	}
-	if _, ok := v.ent.Prev[name]; ok {
-		delete(v.ent.Prev, name)
+	v.ent.Remove(name)
+}
+
+// Entry returns the named entry as of the last Read.
+func (v *VEntry) Entry(name string) (Vers, error) {
+	return v.ent.Get(name)
+}
+
+// Entries returns a copy of the entries as of the last Read.
+func (v *VEntry) Entries() Entries {
+	return v.ent.clone().Version
+}
+
+// String returns the rendered version of the named entry ("" if it
+// does not exist)
+func (v *VEntry) String(name string) string {
+	if ve, ok := v.ent.Version[name]; ok {
+		return ve.String()
 	}
+	return ""
+}
+
+// Fdump writes all entries to w in the given format.
+func (v *VEntry) Fdump(w io.Writer, format string) error {
+	return RenderAll(w, v.ent.Version, format)
+}
+
+// Fprint writes the named entry to w in the given format.
+func (v *VEntry) Fprint(w io.Writer, name, format string) error {
+	return Render(w, v.ent.Version, name, format)
 }
 
-// Dump will dump entries
+// Dump will dump entries to stdout
 func (v *VEntry) Dump(format string) error {
-	// get the type we can handle json or yaml
-	switch format {
-	case "str":
-		fallthrough
-	case "shell":
-		for name, _ := range v.ent.Version {
-			v.Print(name, format)
+	return v.Fdump(os.Stdout, format)
+}
+
+// Print will dump name(d) entries to stdout
+func (v *VEntry) Print(name, format string) error {
+	return v.Fprint(os.Stdout, name, format)
+}
+
+// lock takes the shared (read) or exclusive lock, trying retry times.
+func (v *VEntry) lock(shared bool, retry int) error {
+	var rt int
+	for rt < retry {
+		rt++
+		lock := v.lck.TryLock
+		if shared {
+			lock = v.lck.TryRLock
 		}
-	case "json":
-		out, err := json.MarshalIndent(v.ent.Version, "", "   ")
+		ok, err := lock()
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(out))
-	case "yml":
-		fallthrough
-	case "yaml":
-		out, err := yaml.Marshal(v.ent.Version)
-		if err != nil {
-			return err
+		if ok {
+			return nil
 		}
-		fmt.Println(string(out))
-	default:
-		return fmt.Errorf("unsupported type")
+		time.Sleep(100 * time.Millisecond)
 	}
-	return nil
+	log.Debugf("lock(): did not lock file %s", v.Path())
+	return fmt.Errorf("%s; %w", v.Path(), ErrLockTimeout)
 }
 
-// Print will dump name(d) entries
-func (v *VEntry) Print(name, format string) error {
-	ve, ok := v.ent.Version[name]
-	if !ok {
-		return fmt.Errorf("%s; does not exist", name)
-	}
-	ent := make(Entries)
-	ent[name] = ve
-	// get the type we can handle json or yaml
-	switch format {
-	case "shell":
-		str := fmt.Sprintf("export %s_VERS=%s%d.%d.%d%s", strings.ToUpper(name),
-			ve.Prefix, ve.Major, ve.Minor, ve.Patch, ve.Suffix)
-		fmt.Println(strings.ReplaceAll(str, "-", "_"))
-	case "str":
-		fmt.Printf("%s%d.%d.%d%s\n",
-			ve.Prefix, ve.Major, ve.Minor, ve.Patch, ve.Suffix)
-	case "json":
-		out, err := json.MarshalIndent(ent, "", "   ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(out))
-	case "yml":
-		fallthrough
-	case "yaml":
-		out, err := yaml.Marshal(ent)
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(out))
-	default:
-		return fmt.Errorf("unsupported type")
+// load reads the version file, the caller holds the lock.
+func (v *VEntry) load() error {
+	ent, err := readVersionFile(v.path)
+	if err != nil {
+		return err
 	}
+	ent.SetHistory(v.keep)
+	v.ent = ent
 	return nil
 }
 
 // Read reads the entries file, populates the hash
 func (v *VEntry) Read(retry int) error {
-	var rt int
 	log.Debugf("Read(): v->%#+v", v)
-	for rt < retry {
-		rt++
-		ok, err := v.lck.TryRLock()
-		if err != nil {
-			return err
-		}
-		if ok {
-			defer v.lck.Unlock()
-			v.ent, err = readVersionFile(v.path)
-			return err
-		}
-		time.Sleep(100 * time.Millisecond)
+	if err := v.lock(true, retry); err != nil {
+		return err
 	}
-	log.Debugf("Read(): did not lock file", v.Path())
-	return fmt.Errorf("unable to get lock")
+	defer v.lck.Unlock()
+	return v.load()
 }
 
 // Write will write the entries to stable store.
 func (v *VEntry) Write(retry int) error {
-	var rt int
 	log.Debugf("Write(): v->%#+v", v)
-	for rt < retry {
-		rt++
-		ok, err := v.lck.TryLock()
-		if err != nil {
-			return err
-		}
-		if ok {
-			defer v.lck.Unlock()
-			return writeVersionFile(v.path, v.ent)
-		}
-		time.Sleep(100 * time.Millisecond)
+	if err := v.lock(false, retry); err != nil {
+		return err
+	}
+	defer v.lck.Unlock()
+	return writeVersionFile(v.path, v.ent)
+}
+
+// Update reads the version file under the write lock, runs fn on a
+// copy of it and writes the copy back iff fn succeeds, only then are
+// the changes seen by Entry, Entries etc.
+func (v *VEntry) Update(fn func(*VFile) error) error {
+	if err := v.lock(false, 10); err != nil {
+		return err
+	}
+	defer v.lck.Unlock()
+	if err := v.load(); err != nil {
+		return err
+	}
+	c := v.ent.clone()
+	if err := fn(c); err != nil {
+		return err
+	}
+	if err := c.CheckGroups(); err != nil {
+		return err
+	}
+	if err := writeVersionFile(v.path, c); err != nil {
+		return err
+	}
+	v.ent = c
+	return nil
+}
+
+// Get returns the named entry as currently in the file.
+func (v *VEntry) Get(name string) (Vers, error) {
+	if err := v.Read(10); err != nil {
+		return Vers{}, err
+	}
+	return v.ent.Get(name)
+}
+
+// List returns the entries currently in the file.
+func (v *VEntry) List() (Entries, error) {
+	if err := v.Read(10); err != nil {
+		return nil, err
+	}
+	return v.ent.clone().Version, nil
+}
+
+// Snapshot returns a copy of the whole file as it currently is.
+func (v *VEntry) Snapshot() (*VFile, error) {
+	if err := v.Read(10); err != nil {
+		return nil, err
+	}
+	return v.ent.clone(), nil
+}
+
+// Put will update/add an entry in the file.
+func (v *VEntry) Put(name string, ve Vers) error {
+	return v.Update(func(f *VFile) error {
+		f.Set(name, ve)
+		return nil
+	})
+}
+
+// History returns the previous values of an entry, newest first.
+func (v *VEntry) History(name string) ([]Vers, error) {
+	if err := v.Read(10); err != nil {
+		return nil, err
+	}
+	if _, err := v.ent.Get(name); err != nil {
+		return nil, err
 	}
-	log.Debugf("Write(): did not lock file", v.Path())
-	return fmt.Errorf("unable to get lock")
+	return v.ent.Previous(name), nil
 }
 
 // Bump will inc the value of version field
 func (v *VEntry) Bump(name, what string) error {
-	var rt int
-	for rt < 10 {
-		rt++
-		ok, err := v.lck.TryLock()
-		if err != nil {
-			return err
-		}
-		if ok {
-			defer v.lck.Unlock()
-			v.ent, err = readVersionFile(v.path)
-			ve, ok := v.ent.Version[name]
-			if !ok {
-				return fmt.Errorf("%s; does not exist", name)
-			}
-			// push current values to history
-			v.ent.Prev[name] = *ve
-			switch what {
-			case "major":
-				ve.Major++
-				ve.Minor = 0
-				ve.Patch = 0
-			case "minor":
-				ve.Minor++
-				ve.Patch = 0
-			case "patch":
-				ve.Patch++
-			default:
-				return errors.New("Invalid bump setting")
-			}
-			return writeVersionFile(v.path, v.ent)
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	log.Debugf("Bump(): did not lock file", v.Path())
-	return errors.New("Did not obtain lock")
+	_, err := Bump(v, name, what)
+	return err
 }
 
 // Undo restore previous value
 func (v *VEntry) Undo(name string) error {
-	var rt int
-	for rt < 10 {
-		rt++
-		ok, err := v.lck.TryLock()
-		if err != nil {
-			return err
-		}
-		if ok {
-			defer v.lck.Unlock()
-			v.ent, err = readVersionFile(v.path)
-			if _, ok := v.ent.Version[name]; !ok {
-				return fmt.Errorf("%s; does not exist", name)
-			}
-
-			if ve, ok := v.ent.Prev[name]; ok {
-				v.ent.Version[name] = &ve
-				delete(v.ent.Prev, name)
-				return writeVersionFile(v.path, v.ent)
-			}
-			return fmt.Errorf("%s; previous value does not exist", name)
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	log.Debugf("Bump(): did not lock file", v.Path())
-	return errors.New("Did not obtain lock")
+	_, err := Undo(v, name)
+	return err
 }
 
 // Delete will remove the entry
 func (v *VEntry) Delete(name string) error {
-	var rt int
-	for rt < 10 {
-		rt++
-		ok, err := v.lck.TryLock()
-		if err != nil {
-			return err
-		}
-		if ok {
-			defer v.lck.Unlock()
-			v.ent, err = readVersionFile(v.path)
-			_, ok := v.ent.Version[name]
-			if !ok {
-				return fmt.Errorf("%s; does not exist", name)
-			}
-			v.Rm(name)
-			return writeVersionFile(v.path, v.ent)
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	log.Debugf("Bump(): did not lock file", v.Path())
-	return errors.New("Did not obtain lock")
+	return v.Update(func(f *VFile) error {
+		return f.Remove(name)
+	})
 }
 
-// Unlock and close file
-func (v *VEntry) Close() {
+// Close unlocks and removes the lock file
+func (v *VEntry) Close() error {
 	unix.Unlink(v.lck.Path())
-	v.lck.Close()
+	return v.lck.Close()
 }
@@ -0,0 +1,114 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var goSource = template.Must(template.New("go").Parse(`// Code generated by vers gen go; DO NOT EDIT.
+{{- if .Source}}
+// Source: {{.Source}}
+{{- end}}
+
+package {{.Package}}
+
+const (
+{{- range $i, $e := .Entries}}
+{{- if $i}}
+{{end}}
+	// {{.Ident}}Version is the {{printf "%q" .Name}} version.
+	{{.Ident}}Version = {{printf "%q" .Version}}
+	{{.Ident}}Prefix = {{printf "%q" .Prefix}}
+	{{.Ident}}Major = {{.Major}}
+	{{.Ident}}Minor = {{.Minor}}
+	{{.Ident}}Patch = {{.Patch}}
+	{{.Ident}}Suffix = {{printf "%q" .Suffix}}
+{{- end}}
+)
+`))
+
+// GoIdent turns an entry name into an exported Go identifier
+// (web-ui -> WebUi).
+func GoIdent(name string) string {
+	var b strings.Builder
+	up := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			up = true
+			continue
+		}
+		if up {
+			r = unicode.ToUpper(r)
+			up = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if len(s) == 0 || !unicode.IsLetter([]rune(s)[0]) {
+		s = "V" + s
+	}
+	return s
+}
+
+// IsGoPackage reports whether name can be a package clause's name.
+func IsGoPackage(name string) bool {
+	return token.IsIdentifier(name) && name != "_"
+}
+
+// GoSource returns a gofmt'd Go file declaring constants for each
+// entry (<Ident>Version, Prefix, Major, Minor, Patch and Suffix) in
+// package pkg, source names the version file in the header comment.
+func GoSource(pkg, source string, ents Entries) ([]byte, error) {
+	type goEntry struct {
+		TemplateData
+		Ident string
+	}
+	var buf bytes.Buffer
+
+	if !IsGoPackage(pkg) {
+		return nil, fmt.Errorf("%q is not a Go package name", pkg)
+	}
+	seen := make(map[string]string)
+	data := struct {
+		Package string
+		Source  string
+		Entries []goEntry
+	}{Package: pkg, Source: source}
+	for _, name := range ents.Names() {
+		id := GoIdent(name)
+		if other, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%s and %s both map to Go name %s", other, name, id)
+		}
+		seen[id] = name
+		data.Entries = append(data.Entries, goEntry{NewTemplateData(name, *ents[name]), id})
+	}
+	if err := goSource.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,99 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoIdent(t *testing.T) {
+	for name, want := range map[string]string{
+		"api":       "Api",
+		"web-ui":    "WebUi",
+		"my.lib_v2": "MyLibV2",
+		"2fa":       "V2fa",
+		"-":         "V",
+	} {
+		if got := GoIdent(name); got != want {
+			t.Errorf("GoIdent(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestGoSource(t *testing.T) {
+	ents := Entries{
+		"api":    &Vers{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Suffix: "-rc.1"},
+		"web-ui": &Vers{Major: 2},
+	}
+	src, err := GoSource("version", "versions.yaml", ents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "version_gen.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	if f.Name.Name != "version" {
+		t.Errorf("package %s, want version", f.Name.Name)
+	}
+	if !strings.HasPrefix(string(src), "// Code generated by vers gen go; DO NOT EDIT.\n// Source: versions.yaml\n") {
+		t.Errorf("header:\n%s", src)
+	}
+
+	got := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if vs, ok := n.(*ast.ValueSpec); ok {
+			got[vs.Names[0].Name] = vs.Values[0].(*ast.BasicLit).Value
+		}
+		return true
+	})
+	want := map[string]string{
+		"ApiVersion": `"v1.2.3-rc.1"`, "ApiPrefix": `"v"`, "ApiMajor": "1", "ApiMinor": "2", "ApiPatch": "3", "ApiSuffix": `"-rc.1"`,
+		"WebUiVersion": `"2.0.0"`, "WebUiPrefix": `""`, "WebUiMajor": "2", "WebUiMinor": "0", "WebUiPatch": "0", "WebUiSuffix": `""`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("constants %v\nwant %v", got, want)
+	}
+}
+
+func TestGoSourceErrors(t *testing.T) {
+	ents := Entries{"api": &Vers{Major: 1}}
+	for _, pkg := range []string{"my-pkg", "", "_", "func", "1st"} {
+		if _, err := GoSource(pkg, "", ents); err == nil {
+			t.Errorf("package %q: no error", pkg)
+		}
+	}
+	if _, err := GoSource("main", "", Entries{"web-ui": &Vers{}, "web.ui": &Vers{}}); err == nil {
+		t.Error("web-ui and web.ui: no error")
+	}
+	src, err := GoSource("main", "", Entries{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		t.Errorf("no entries: %v\n%s", err, src)
+	}
+}
@@ -0,0 +1,172 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GroupPrefix marks a group name where an entry name is expected
+// ("@tools").
+const GroupPrefix = "@"
+
+// Group adds members to a group, creating it if need be.  An entry can
+// only be in one group and the members must share one version.
+func (f *VFile) Group(group string, members ...string) error {
+	group = strings.TrimPrefix(group, GroupPrefix)
+	cur := append([]string(nil), f.Groups[group]...)
+	for _, m := range members {
+		if _, ok := f.Version[m]; !ok {
+			return entryErr(m, ErrEntryNotFound)
+		}
+		if g := f.GroupOf(m); len(g) != 0 && g != group {
+			return entryErr(m, fmt.Errorf("%w (%s%s)", ErrInGroup, GroupPrefix, g))
+		}
+		if !contains(cur, m) {
+			cur = append(cur, m)
+		}
+	}
+	sort.Strings(cur)
+	if err := f.checkGroup(group, cur); err != nil {
+		return err
+	}
+	if f.Groups == nil {
+		f.Groups = make(Groups)
+	}
+	f.Groups[group] = cur
+	return nil
+}
+
+// Ungroup removes members from a group, all of them (dropping the
+// group) if none are given.
+func (f *VFile) Ungroup(group string, members ...string) error {
+	group = strings.TrimPrefix(group, GroupPrefix)
+	if _, ok := f.Groups[group]; !ok {
+		return entryErr(GroupPrefix+group, ErrEntryNotFound)
+	}
+	var keep []string
+	if len(members) != 0 {
+		for _, m := range f.Groups[group] {
+			if !contains(members, m) {
+				keep = append(keep, m)
+			}
+		}
+	}
+	if len(keep) == 0 {
+		delete(f.Groups, group)
+	} else {
+		f.Groups[group] = keep
+	}
+	return nil
+}
+
+// GroupOf returns the group an entry is in, "" for none.
+func (f *VFile) GroupOf(name string) string {
+	for g, members := range f.Groups {
+		if contains(members, name) {
+			return g
+		}
+	}
+	return ""
+}
+
+// Resolve returns the entries a name stands for: the members of a
+// group given as "@group", or an entry and the other members of its
+// group.
+func (f *VFile) Resolve(name string) ([]string, error) {
+	if strings.HasPrefix(name, GroupPrefix) {
+		members, ok := f.Groups[strings.TrimPrefix(name, GroupPrefix)]
+		if !ok {
+			return nil, entryErr(name, ErrEntryNotFound)
+		}
+		return append([]string(nil), members...), nil
+	}
+	if _, ok := f.Version[name]; !ok {
+		return nil, entryErr(name, ErrEntryNotFound)
+	}
+	if g := f.GroupOf(name); len(g) != 0 {
+		return append([]string(nil), f.Groups[g]...), nil
+	}
+	return []string{name}, nil
+}
+
+// SetAll sets the entries name stands for (see Resolve) to ve, a new
+// entry is added.  If ve has no prefix each entry gets prefix(entry),
+// or keeps its own when prefix is nil.
+func (f *VFile) SetAll(name string, ve Vers, prefix func(string) string) ([]string, error) {
+	names := []string{name}
+	if _, ok := f.Version[name]; ok || strings.HasPrefix(name, GroupPrefix) {
+		var err error
+		if names, err = f.Resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range names {
+		nv := ve
+		if len(nv.Prefix) == 0 {
+			if prefix != nil {
+				nv.Prefix = prefix(n)
+			} else if cur, ok := f.Version[n]; ok {
+				nv.Prefix = cur.Prefix
+			}
+		}
+		f.Set(n, nv)
+	}
+	return names, nil
+}
+
+// checkGroup returns ErrDiverged if members do not share one version
+// (their prefixes may differ).
+func (f *VFile) checkGroup(group string, members []string) error {
+	var first string
+	for _, m := range members {
+		ve, ok := f.Version[m]
+		if !ok {
+			continue
+		}
+		if len(first) == 0 {
+			first = m
+			continue
+		}
+		if fv := f.Version[first]; fv.Semver() != ve.Semver() {
+			return entryErr(GroupPrefix+group, fmt.Errorf("%w (%s %s, %s %s)", ErrDiverged, first, fv, m, ve))
+		}
+	}
+	return nil
+}
+
+// CheckGroups returns ErrDiverged if the members of any group do not
+// share one version.
+func (f *VFile) CheckGroups() error {
+	var names []string
+	for g := range f.Groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		if err := f.checkGroup(g, f.Groups[g]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "sync"
+
+// MemStore keeps entries in memory, it is handy for tests and for
+// programs that manage versions without a file.
+type MemStore struct {
+	mu  sync.RWMutex
+	ent *VFile
+}
+
+var _ Store = (*MemStore)(nil)
+
+// NewMemStore returns an empty in-memory store keeping keep previous
+// values per entry.
+func NewMemStore(keep int) *MemStore {
+	return &MemStore{ent: newVFile(keep)}
+}
+
+// Get returns the named entry.
+func (m *MemStore) Get(name string) (Vers, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ent.Get(name)
+}
+
+// List returns a copy of all the entries.
+func (m *MemStore) List() (Entries, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ent.clone().Version, nil
+}
+
+// Snapshot returns a copy of the whole contents.
+func (m *MemStore) Snapshot() (*VFile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ent.clone(), nil
+}
+
+// Put will update/add an entry
+func (m *MemStore) Put(name string, ve Vers) error {
+	return m.Update(func(f *VFile) error {
+		f.Set(name, ve)
+		return nil
+	})
+}
+
+// Delete will remove the entry
+func (m *MemStore) Delete(name string) error {
+	return m.Update(func(f *VFile) error {
+		return f.Remove(name)
+	})
+}
+
+// Update runs fn on a copy of the contents and keeps the copy iff fn
+// succeeds.
+func (m *MemStore) Update(fn func(*VFile) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.ent.clone()
+	if err := fn(c); err != nil {
+		return err
+	}
+	if err := c.CheckGroups(); err != nil {
+		return err
+	}
+	m.ent = c
+	return nil
+}
+
+// History returns the previous values of an entry, newest first.
+func (m *MemStore) History(name string) ([]Vers, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, err := m.ent.Get(name); err != nil {
+		return nil, err
+	}
+	return m.ent.Previous(name), nil
+}
+
+// Close is a no-op
+func (m *MemStore) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "sort"
+
+// checkTarget makes sure an entry can be renamed or copied onto dst,
+// removing dst if force is set.
+func (f *VFile) checkTarget(src, dst string, force bool) error {
+	if _, ok := f.Version[src]; !ok {
+		return entryErr(src, ErrEntryNotFound)
+	}
+	if _, ok := f.Version[dst]; !ok {
+		return nil
+	}
+	if !force || src == dst {
+		return entryErr(dst, ErrExists)
+	}
+	return f.Remove(dst)
+}
+
+// Rename moves an entry with its history, dependencies and group to
+// dst, the entries depending on it depend on dst instead.  An existing
+// dst is only replaced if force is set.
+func (f *VFile) Rename(src, dst string, force bool) error {
+	if err := f.checkTarget(src, dst, force); err != nil {
+		return err
+	}
+	f.Version[dst] = f.Version[src]
+	delete(f.Version, src)
+	if ve, ok := f.Prev[src]; ok {
+		f.Prev[dst] = ve
+		delete(f.Prev, src)
+	}
+	if h, ok := f.History[src]; ok {
+		f.History[dst] = h
+		delete(f.History, src)
+	}
+	if d, ok := f.Deps[src]; ok {
+		f.Deps[dst] = d
+		delete(f.Deps, src)
+	}
+	for _, deps := range f.Deps {
+		for i, d := range deps {
+			if d == src {
+				deps[i] = dst
+			}
+		}
+		sort.Strings(deps)
+	}
+	if g := f.GroupOf(src); len(g) != 0 {
+		members := f.Groups[g]
+		for i, m := range members {
+			if m == src {
+				members[i] = dst
+			}
+		}
+		sort.Strings(members)
+	}
+	return nil
+}
+
+// Copy duplicates an entry with its history and dependencies as dst,
+// which is in no group and has no dependents.  An existing dst is only
+// replaced if force is set.
+func (f *VFile) Copy(src, dst string, force bool) error {
+	if err := f.checkTarget(src, dst, force); err != nil {
+		return err
+	}
+	ve := *f.Version[src]
+	f.Version[dst] = &ve
+	if pv, ok := f.Prev[src]; ok {
+		f.Prev[dst] = pv
+	}
+	if h, ok := f.History[src]; ok {
+		f.History[dst] = append([]Vers(nil), h...)
+	}
+	if d, ok := f.Deps[src]; ok {
+		f.Deps[dst] = append([]string(nil), d...)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// renameVFile returns a VFile where api (with history) depends on lib,
+// web and cli depend on api, and api and web are in group "site".
+func renameVFile(t *testing.T) *VFile {
+	t.Helper()
+	f := newVFile(3)
+	for _, v := range []Vers{{Major: 1}, {Major: 1, Minor: 1}, {Major: 1, Minor: 2}} {
+		f.Set("api", v)
+	}
+	f.Set("web", Vers{Major: 1, Minor: 2})
+	f.Set("lib", Vers{Major: 3})
+	f.Set("cli", Vers{Major: 4})
+	f.Set("tool", Vers{Major: 5})
+	f.Set("tool", Vers{Major: 6})
+	for n, d := range map[string][]string{"api": {"lib"}, "web": {"api"}, "cli": {"api", "lib"}} {
+		if err := f.Depend(n, d...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Group("site", "api", "web"); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// wantGone fails if name is still anywhere in f.
+func wantGone(t *testing.T, f *VFile, name string) {
+	t.Helper()
+	if _, ok := f.Version[name]; ok {
+		t.Errorf("%s still has a version", name)
+	}
+	if _, ok := f.Prev[name]; ok {
+		t.Errorf("%s still has a previous version", name)
+	}
+	if _, ok := f.History[name]; ok {
+		t.Errorf("%s still has history", name)
+	}
+	if _, ok := f.Deps[name]; ok {
+		t.Errorf("%s still has dependencies", name)
+	}
+	if d := f.Dependents(name); len(d) != 0 {
+		t.Errorf("%s still has dependents %v", name, d)
+	}
+	if g := f.GroupOf(name); len(g) != 0 {
+		t.Errorf("%s is still in group %s", name, g)
+	}
+}
+
+func TestRename(t *testing.T) {
+	f := renameVFile(t)
+	old := f.clone()
+	if err := f.Rename("api", "server", false); err != nil {
+		t.Fatal(err)
+	}
+	wantGone(t, f, "api")
+	if *f.Version["server"] != *old.Version["api"] {
+		t.Errorf("version %s, want %s", f.Version["server"], old.Version["api"])
+	}
+	if !reflect.DeepEqual(f.Previous("server"), old.Previous("api")) || len(f.Previous("server")) != 2 {
+		t.Errorf("history %v, want %v", f.Previous("server"), old.Previous("api"))
+	}
+	if got := f.DependsOn("server"); !reflect.DeepEqual(got, []string{"lib"}) {
+		t.Errorf("server depends on %v, want [lib]", got)
+	}
+	if got := f.Dependents("server"); !reflect.DeepEqual(got, []string{"cli", "web"}) {
+		t.Errorf("server dependents %v, want [cli web]", got)
+	}
+	if got := f.DependsOn("cli"); !reflect.DeepEqual(got, []string{"lib", "server"}) {
+		t.Errorf("cli depends on %v, want [lib server]", got)
+	}
+	if got := f.Groups["site"]; !reflect.DeepEqual(got, []string{"server", "web"}) {
+		t.Errorf("group site %v, want [server web]", got)
+	}
+	if err := f.CheckGroups(); err != nil {
+		t.Error(err)
+	}
+	if _, err := f.Order(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenameForce(t *testing.T) {
+	f := renameVFile(t)
+	api := *f.Version["api"]
+	if err := f.Rename("api", "tool", false); !errors.Is(err, ErrExists) {
+		t.Fatalf("Rename onto tool: %v, want ErrExists", err)
+	}
+	if !reflect.DeepEqual(f, renameVFile(t)) {
+		t.Fatal("a failed Rename changed the file")
+	}
+	if err := f.Rename("api", "tool", true); err != nil {
+		t.Fatal(err)
+	}
+	wantGone(t, f, "api")
+	if *f.Version["tool"] != api || len(f.Previous("tool")) != 2 || f.Previous("tool")[0].Minor != 1 {
+		t.Errorf("tool %s history %v, want api's", f.Version["tool"], f.Previous("tool"))
+	}
+	if f.GroupOf("tool") != "site" {
+		t.Errorf("tool is in %q, want site", f.GroupOf("tool"))
+	}
+
+	// lib has dependents, force does not drop them
+	f = renameVFile(t)
+	if err := f.Rename("tool", "lib", true); !errors.Is(err, ErrHasDependents) {
+		t.Errorf("Rename onto lib: %v, want ErrHasDependents", err)
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	for _, rename := range []func(f *VFile, src, dst string, force bool) error{(*VFile).Rename, (*VFile).Copy} {
+		f := renameVFile(t)
+		if err := rename(f, "gone", "new", false); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("missing source: %v, want ErrEntryNotFound", err)
+		}
+		if err := rename(f, "api", "api", true); !errors.Is(err, ErrExists) {
+			t.Errorf("onto itself: %v, want ErrExists", err)
+		}
+		var ee *EntryError
+		if err := rename(f, "api", "web", false); !errors.Is(err, ErrExists) || !errors.As(err, &ee) || ee.Name != "web" {
+			t.Errorf("onto web: %v, want ErrExists for web", err)
+		}
+		if !reflect.DeepEqual(f, renameVFile(t)) {
+			t.Error("a failed rename changed the file")
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	f := renameVFile(t)
+	if err := f.Copy("api", "api2", false); err != nil {
+		t.Fatal(err)
+	}
+	if *f.Version["api2"] != *f.Version["api"] || !reflect.DeepEqual(f.Previous("api2"), f.Previous("api")) {
+		t.Errorf("api2 %s %v, want api's %s %v", f.Version["api2"], f.Previous("api2"), f.Version["api"], f.Previous("api"))
+	}
+	if got := f.DependsOn("api2"); !reflect.DeepEqual(got, []string{"lib"}) {
+		t.Errorf("api2 depends on %v, want [lib]", got)
+	}
+	if d := f.Dependents("api2"); len(d) != 0 {
+		t.Errorf("api2 has dependents %v", d)
+	}
+	if g := f.GroupOf("api2"); len(g) != 0 {
+		t.Errorf("api2 is in group %s", g)
+	}
+	if got := f.Dependents("api"); !reflect.DeepEqual(got, []string{"cli", "web"}) {
+		t.Errorf("api dependents %v, want [cli web]", got)
+	}
+
+	// the copy does not share with the original
+	f.Version["api2"].Major = 9
+	f.History["api2"][0].Major = 9
+	f.Deps["api2"][0] = "cli"
+	if f.Version["api"].Major == 9 || f.History["api"][0].Major == 9 || f.Deps["api"][0] != "lib" {
+		t.Error("changing the copy changed api")
+	}
+	if err := f.CheckGroups(); err != nil {
+		t.Error(err)
+	}
+
+	if err := f.Copy("api", "tool", false); !errors.Is(err, ErrExists) {
+		t.Errorf("Copy onto tool: %v, want ErrExists", err)
+	}
+	if err := f.Copy("api", "tool", true); err != nil {
+		t.Fatal(err)
+	}
+	if *f.Version["tool"] != *f.Version["api"] || len(f.Previous("tool")) != 2 {
+		t.Errorf("tool %s %v, want api's", f.Version["tool"], f.Previous("tool"))
+	}
+}
+
+func TestStoreRename(t *testing.T) {
+	stores(t, 3, func(t *testing.T, s Store) {
+		mustPut(t, s, "api", "v1.0.0")
+		mustPut(t, s, "api", "v1.1.0")
+		mustPut(t, s, "web", "v2.0.0")
+		err := s.Update(func(f *VFile) error { return f.Rename("api", "web", false) })
+		if !errors.Is(err, ErrExists) {
+			t.Fatalf("Rename onto web: %v, want ErrExists", err)
+		}
+		wantVersion(t, s, "web", "v2.0.0")
+		if err := s.Update(func(f *VFile) error { return f.Rename("api", "web", true) }); err != nil {
+			t.Fatal(err)
+		}
+		wantVersion(t, s, "web", "v1.1.0")
+		if _, err := s.Get("api"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Get api: %v, want ErrEntryNotFound", err)
+		}
+		if h, err := s.History("web"); err != nil || len(h) != 1 || h[0].String() != "v1.0.0" {
+			t.Errorf("web history %v, %v, want [v1.0.0]", h, err)
+		}
+	})
+}
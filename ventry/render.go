@@ -0,0 +1,150 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Names returns the entry names in sorted order.
+func (e Entries) Names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Renderer writes entries in one of the output formats: str, json,
+// yaml, an environment variable format (see IsEnvFormat) or a template
+// (see TemplateFormat).
+type Renderer struct {
+	Env EnvOptions
+}
+
+// RenderAll writes all the entries to w in the given format using the
+// default options.
+func RenderAll(w io.Writer, ents Entries, format string) error {
+	return Renderer{}.RenderAll(w, ents, format)
+}
+
+// Render writes the named entry to w in the given format using the
+// default options.
+func Render(w io.Writer, ents Entries, name, format string) error {
+	return Renderer{}.Render(w, ents, name, format)
+}
+
+// RenderAll writes all the entries to w, templates are run once per
+// entry.
+func (r Renderer) RenderAll(w io.Writer, ents Entries, format string) error {
+	t, err := formatTemplate(format)
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		for _, name := range ents.Names() {
+			if err := renderTemplate(w, t, name, *ents[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if IsEnvFormat(format) {
+		for _, name := range ents.Names() {
+			if err := renderEnv(w, format, name, *ents[name], r.Env); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// get the type we can handle json or yaml
+	switch format {
+	case "str":
+		for _, name := range ents.Names() {
+			fmt.Fprintln(w, ents[name])
+		}
+	case "json":
+		out, err := json.MarshalIndent(ents, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	case "yml":
+		fallthrough
+	case "yaml":
+		out, err := yaml.Marshal(ents)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	default:
+		return fmt.Errorf("%q; %w", format, ErrUnsupportedFormat)
+	}
+	return nil
+}
+
+// Render writes the named entry to w.
+func (r Renderer) Render(w io.Writer, ents Entries, name, format string) error {
+	ve, ok := ents[name]
+	if !ok {
+		return entryErr(name, ErrEntryNotFound)
+	}
+	t, err := formatTemplate(format)
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		return renderTemplate(w, t, name, *ve)
+	}
+	if IsEnvFormat(format) {
+		return renderEnv(w, format, name, *ve, r.Env)
+	}
+	ent := make(Entries)
+	ent[name] = ve
+	// get the type we can handle json or yaml
+	switch format {
+	case "str":
+		fmt.Fprintln(w, ve)
+	case "json":
+		out, err := json.MarshalIndent(ent, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	case "yml":
+		fallthrough
+	case "yaml":
+		out, err := yaml.Marshal(ent)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	default:
+		return fmt.Errorf("%q; %w", format, ErrUnsupportedFormat)
+	}
+	return nil
+}
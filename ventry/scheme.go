@@ -0,0 +1,173 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SchemeLoose accepts any prefix and suffix.
+	SchemeLoose = "loose"
+	// SchemeSemver requires the suffix to be a SemVer 2.0 pre-release
+	// and/or build metadata (e.g. "-rc.1+build.5").
+	SchemeSemver = "semver"
+)
+
+var semverSuffix = regexp.MustCompile(`^(-(0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*)(\.(0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*))*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// Check validates the version against a versioning scheme.
+func (v *Vers) Check(scheme string) error {
+	if v.Major < 0 || v.Minor < 0 || v.Patch < 0 {
+		return fmt.Errorf("%d.%d.%d; negative version numbers; %w", v.Major, v.Minor, v.Patch, ErrInvalidVersion)
+	}
+	switch scheme {
+	case "", SchemeLoose:
+		return nil
+	case SchemeSemver:
+		if !semverSuffix.MatchString(v.Suffix) {
+			return fmt.Errorf("%q; not a semver pre-release/build suffix; %w", v.Suffix, ErrInvalidVersion)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s; unknown version scheme", scheme)
+}
+
+var versRE = regexp.MustCompile(`^(.*?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)([^0-9.].*)?$`)
+
+// Parse reads a rendered version such as "v1.2.3-rc1" or "2.5.0".
+func Parse(s string) (Vers, error) {
+	m := versRE.FindStringSubmatch(s)
+	if m == nil {
+		return Vers{}, fmt.Errorf("%q; not of the form [prefix]major.minor.patch[suffix]; %w", s, ErrInvalidVersion)
+	}
+	ve := Vers{Prefix: m[1], Suffix: m[5]}
+	// the regexp only matches digits so these can only fail on overflow
+	var err error
+	if ve.Major, err = strconv.Atoi(m[2]); err != nil {
+		return Vers{}, fmt.Errorf("%q; %w", s, ErrInvalidVersion)
+	}
+	if ve.Minor, err = strconv.Atoi(m[3]); err != nil {
+		return Vers{}, fmt.Errorf("%q; %w", s, ErrInvalidVersion)
+	}
+	if ve.Patch, err = strconv.Atoi(m[4]); err != nil {
+		return Vers{}, fmt.Errorf("%q; %w", s, ErrInvalidVersion)
+	}
+	return ve, nil
+}
+
+// Compare orders two versions by their numbers and then SemVer
+// pre-release precedence (1.0.0-rc.1 < 1.0.0); the prefix and build
+// metadata are ignored.  It returns -1, 0 or 1.
+func Compare(a, b Vers) int {
+	for _, d := range [...]int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	pa, pb := a.PreRelease(), b.PreRelease()
+	switch {
+	case pa == pb:
+		return 0
+	case len(pa) == 0:
+		return 1
+	case len(pb) == 0:
+		return -1
+	}
+	ia, ib := strings.Split(pa, "."), strings.Split(pb, ".")
+	for i := 0; i < len(ia) && i < len(ib); i++ {
+		if c := compareIdent(ia[i], ib[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(ia) < len(ib):
+		return -1
+	case len(ia) > len(ib):
+		return 1
+	}
+	return 0
+}
+
+// compareIdent compares pre-release identifiers, numbers sort before
+// (and numerically among) alphanumerics.
+func compareIdent(a, b string) int {
+	na, ea := strconv.Atoi(a)
+	nb, eb := strconv.Atoi(b)
+	switch {
+	case ea == nil && eb == nil:
+		if na < nb {
+			return -1
+		}
+		if na > nb {
+			return 1
+		}
+		return 0
+	case ea == nil:
+		return -1
+	case eb == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// PreRelease returns the pre-release part of the suffix ("rc.1" of
+// "-rc.1+build.5").
+func (v Vers) PreRelease() string {
+	s := v.Suffix
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimLeft(s, "-.")
+}
+
+// Build returns the build metadata part of the suffix ("build.5" of
+// "-rc.1+build.5").
+func (v Vers) Build() string {
+	if i := strings.IndexByte(v.Suffix, '+'); i >= 0 {
+		return v.Suffix[i+1:]
+	}
+	return ""
+}
+
+// Dev returns the development version distance commits after v: the
+// next patch (or, for a pre-release, v's pre-release) with "dev.N"
+// appended and build as its build metadata (1.4.2-dev.7+g3fa2c1d).
+func (v Vers) Dev(distance int, build string) Vers {
+	d := v
+	if pre := v.PreRelease(); len(pre) != 0 {
+		d.Suffix = fmt.Sprintf("-%s.dev.%d", pre, distance)
+	} else {
+		d.Patch++
+		d.Suffix = fmt.Sprintf("-dev.%d", distance)
+	}
+	if len(build) != 0 {
+		d.Suffix += "+" + build
+	}
+	d.Changed, d.By = nil, ""
+	return d
+}
@@ -0,0 +1,257 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// Store is where version entries are kept.  The version file (VEntry)
+// and MemStore are the implementations provided by this package.
+type Store interface {
+	// Get returns the named entry.
+	Get(name string) (Vers, error)
+	// List returns all the entries.
+	List() (Entries, error)
+	// Snapshot returns a copy of the whole contents.
+	Snapshot() (*VFile, error)
+	// Put will update/add an entry, pushing the old value to history.
+	Put(name string, ve Vers) error
+	// Delete will remove the entry and its history.
+	Delete(name string) error
+	// Update runs fn against the current contents while holding the
+	// write lock, the changes are only saved if fn returns nil and the
+	// members of every group still share one version.
+	Update(fn func(*VFile) error) error
+	// History returns the previous values of an entry, newest first.
+	History(name string) ([]Vers, error)
+	// Close releases the store.
+	Close() error
+}
+
+// newVFile returns an empty VFile keeping keep previous values.
+func newVFile(keep int) *VFile {
+	f := &VFile{
+		Version: make(Entries),
+		Prev:    make(Rollback),
+		History: make(History),
+	}
+	f.SetHistory(keep)
+	return f
+}
+
+// SetHistory sets how many previous values are kept per entry
+// (anything less than one keeps a single value).
+func (f *VFile) SetHistory(keep int) {
+	if keep < 1 {
+		keep = 1
+	}
+	f.keep = keep
+}
+
+// Reset drops all entries, history, dependencies and groups.
+func (f *VFile) Reset() {
+	f.Version = make(Entries)
+	f.Prev = make(Rollback)
+	f.History = make(History)
+	f.Deps = nil
+	f.Groups = nil
+}
+
+// clone returns a deep copy of f.
+func (f *VFile) clone() *VFile {
+	c := newVFile(f.keep)
+	for name, ve := range f.Version {
+		cp := *ve
+		c.Version[name] = &cp
+	}
+	for name, ve := range f.Prev {
+		c.Prev[name] = ve
+	}
+	for name, h := range f.History {
+		c.History[name] = append([]Vers(nil), h...)
+	}
+	if f.Deps != nil {
+		c.Deps = make(Deps)
+		for name, d := range f.Deps {
+			c.Deps[name] = append([]string(nil), d...)
+		}
+	}
+	if f.Groups != nil {
+		c.Groups = make(Groups)
+		for name, m := range f.Groups {
+			c.Groups[name] = append([]string(nil), m...)
+		}
+	}
+	return c
+}
+
+// push saves the current value of an entry to the rollback hash,
+// shifting the older value into the history when we keep more than one.
+func (f *VFile) push(name string, ve Vers) {
+	if old, ok := f.Prev[name]; ok && f.keep > 1 {
+		h := append([]Vers{old}, f.History[name]...)
+		if len(h) > f.keep-1 {
+			h = h[:f.keep-1]
+		}
+		f.History[name] = h
+	}
+	f.Prev[name] = ve
+}
+
+// pop restores the rollback value of an entry and moves the newest
+// history item (if any) into its place.
+func (f *VFile) pop(name string) (Vers, bool) {
+	ve, ok := f.Prev[name]
+	if !ok {
+		return ve, false
+	}
+	delete(f.Prev, name)
+	if h := f.History[name]; len(h) > 0 {
+		f.Prev[name] = h[0]
+		if len(h) > 1 {
+			f.History[name] = h[1:]
+		} else {
+			delete(f.History, name)
+		}
+	}
+	return ve, true
+}
+
+// Get returns the named entry.
+func (f *VFile) Get(name string) (Vers, error) {
+	ve, ok := f.Version[name]
+	if !ok {
+		return Vers{}, entryErr(name, ErrEntryNotFound)
+	}
+	return *ve, nil
+}
+
+// Set will update/add an entry, stamping it with the time and user
+func (f *VFile) Set(name string, ve Vers) {
+	if cur, ok := f.Version[name]; ok {
+		f.push(name, *cur)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	ve.Changed = &now
+	ve.By = changedBy()
+	f.Version[name] = &ve
+}
+
+// changedBy names the user making a change.
+func changedBy() string {
+	if u, err := user.Current(); err == nil && len(u.Username) != 0 {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Remove will remove an entry, its history and dependencies, it is
+// an error for other entries to depend on it.
+func (f *VFile) Remove(name string) error {
+	if _, ok := f.Version[name]; !ok {
+		return entryErr(name, ErrEntryNotFound)
+	}
+	if d := f.Dependents(name); len(d) != 0 {
+		return entryErr(name, fmt.Errorf("%w (%s)", ErrHasDependents, strings.Join(d, ", ")))
+	}
+	delete(f.Version, name)
+	delete(f.Prev, name)
+	delete(f.History, name)
+	delete(f.Deps, name)
+	if g := f.GroupOf(name); len(g) != 0 {
+		f.Ungroup(g, name)
+	}
+	return nil
+}
+
+// Bump will inc the value of version field (one of major, minor or patch)
+func (f *VFile) Bump(name, what string) (Vers, error) {
+	ve, ok := f.Version[name]
+	if !ok {
+		return Vers{}, entryErr(name, ErrEntryNotFound)
+	}
+	nv := *ve
+	switch what {
+	case "major":
+		nv.Major++
+		nv.Minor = 0
+		nv.Patch = 0
+	case "minor":
+		nv.Minor++
+		nv.Patch = 0
+	case "patch":
+		nv.Patch++
+	default:
+		return Vers{}, fmt.Errorf("%q; %w", what, ErrInvalidBump)
+	}
+	f.Set(name, nv)
+	return nv, nil
+}
+
+// Undo restores the previous value of an entry
+func (f *VFile) Undo(name string) (Vers, error) {
+	if _, ok := f.Version[name]; !ok {
+		return Vers{}, entryErr(name, ErrEntryNotFound)
+	}
+	ve, ok := f.pop(name)
+	if !ok {
+		return Vers{}, entryErr(name, ErrNoHistory)
+	}
+	f.Version[name] = &ve
+	return ve, nil
+}
+
+// Previous returns the previous values of an entry, newest first.
+func (f *VFile) Previous(name string) []Vers {
+	var h []Vers
+	if ve, ok := f.Prev[name]; ok {
+		h = append(h, ve)
+		h = append(h, f.History[name]...)
+	}
+	return h
+}
+
+// Bump increments an entry in s, returning the new value.
+func Bump(s Store, name, what string) (Vers, error) {
+	var nv Vers
+	err := s.Update(func(f *VFile) error {
+		var err error
+		nv, err = f.Bump(name, what)
+		return err
+	})
+	return nv, err
+}
+
+// Undo restores the previous value of an entry in s, returning it.
+func Undo(s Store, name string) (Vers, error) {
+	var ve Vers
+	err := s.Update(func(f *VFile) error {
+		var err error
+		ve, err = f.Undo(name)
+		return err
+	})
+	return ve, err
+}
@@ -0,0 +1,266 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stores runs a test against every Store implementation, each made
+// empty and keeping keep previous values.
+func stores(t *testing.T, keep int, test func(t *testing.T, s Store)) {
+	t.Run("mem", func(t *testing.T) {
+		test(t, NewMemStore(keep))
+	})
+	t.Run("file", func(t *testing.T) {
+		s, done := fileStore(t, keep)
+		defer done()
+		test(t, s)
+	})
+}
+
+// fileStore returns an empty version file store.
+func fileStore(t *testing.T, keep int) (*VEntry, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ventry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := Open(filepath.Join(dir, "v.yaml"), true)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	v.SetHistory(keep)
+	return v, func() {
+		v.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// mustPut puts version ("v1.2.3") in the store.
+func mustPut(t *testing.T, s Store, name, version string) {
+	t.Helper()
+	ve := Vers{Prefix: "v"}
+	if _, err := fmt.Sscanf(version, "v%d.%d.%d", &ve.Major, &ve.Minor, &ve.Patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(name, ve); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantVersion(t *testing.T, s Store, name, want string) {
+	t.Helper()
+	ve, err := s.Get(name)
+	if err != nil {
+		t.Fatalf("Get(%q): %s", name, err)
+	}
+	if ve.String() != want {
+		t.Errorf("%s is %s, want %s", name, ve, want)
+	}
+}
+
+func TestStoreGetPut(t *testing.T) {
+	stores(t, 1, func(t *testing.T, s Store) {
+		if _, err := s.Get("api"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Get of a missing entry: %v, want ErrEntryNotFound", err)
+		}
+		mustPut(t, s, "api", "v1.0.0")
+		mustPut(t, s, "web", "v2.0.0")
+		wantVersion(t, s, "api", "v1.0.0")
+		ents, err := s.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if names := ents.Names(); len(names) != 2 || names[0] != "api" || names[1] != "web" {
+			t.Errorf("List has %v, want [api web]", names)
+		}
+		if ve, _ := s.Get("api"); ve.Changed == nil || len(ve.By) == 0 {
+			t.Errorf("Put did not stamp the entry: %+v", ve)
+		}
+	})
+}
+
+func TestStoreCopies(t *testing.T) {
+	stores(t, 1, func(t *testing.T, s Store) {
+		mustPut(t, s, "api", "v1.0.0")
+		ents, err := s.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ents["api"].Major = 9
+		f, err := s.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Version["api"].Major = 8
+		f.Version["web"] = &Vers{Major: 1}
+		wantVersion(t, s, "api", "v1.0.0")
+		if _, err := s.Get("web"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("a change to the snapshot reached the store")
+		}
+	})
+}
+
+func TestStoreHistory(t *testing.T) {
+	stores(t, 3, func(t *testing.T, s Store) {
+		for _, v := range []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0"} {
+			mustPut(t, s, "api", v)
+		}
+		h, err := s.History("api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, ve := range h {
+			got = append(got, ve.String())
+		}
+		want := []string{"v1.3.0", "v1.2.0", "v1.1.0"}
+		if len(got) != len(want) {
+			t.Fatalf("History is %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("History is %v, want %v", got, want)
+			}
+		}
+		for _, want := range []string{"v1.3.0", "v1.2.0", "v1.1.0"} {
+			ve, err := Undo(s, "api")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ve.String() != want {
+				t.Errorf("Undo gave %s, want %s", ve, want)
+			}
+		}
+		if _, err := Undo(s, "api"); !errors.Is(err, ErrNoHistory) {
+			t.Errorf("Undo past the history: %v, want ErrNoHistory", err)
+		}
+		if _, err := s.History("web"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("History of a missing entry: %v, want ErrEntryNotFound", err)
+		}
+	})
+}
+
+func TestStoreBump(t *testing.T) {
+	stores(t, 1, func(t *testing.T, s Store) {
+		mustPut(t, s, "api", "v1.2.3")
+		for _, tt := range []struct{ what, want string }{
+			{"patch", "v1.2.4"}, {"minor", "v1.3.0"}, {"major", "v2.0.0"},
+		} {
+			nv, err := Bump(s, "api", tt.what)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nv.String() != tt.want {
+				t.Errorf("Bump %s gave %s, want %s", tt.what, nv, tt.want)
+			}
+			wantVersion(t, s, "api", tt.want)
+		}
+		if _, err := Bump(s, "api", "huge"); !errors.Is(err, ErrInvalidBump) {
+			t.Errorf("Bump huge: %v, want ErrInvalidBump", err)
+		}
+		if _, err := Bump(s, "web", "patch"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Bump of a missing entry: %v, want ErrEntryNotFound", err)
+		}
+	})
+}
+
+func TestStoreDelete(t *testing.T) {
+	stores(t, 1, func(t *testing.T, s Store) {
+		mustPut(t, s, "api", "v1.0.0")
+		mustPut(t, s, "api", "v1.1.0")
+		if err := s.Delete("api"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get("api"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Get after Delete: %v, want ErrEntryNotFound", err)
+		}
+		// a new entry of the same name starts without history
+		mustPut(t, s, "api", "v3.0.0")
+		if h, _ := s.History("api"); len(h) != 0 {
+			t.Errorf("History after Delete is %v, want none", h)
+		}
+		if err := s.Delete("web"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("Delete of a missing entry: %v, want ErrEntryNotFound", err)
+		}
+	})
+}
+
+func TestStoreUpdateFails(t *testing.T) {
+	boom := errors.New("boom")
+	stores(t, 1, func(t *testing.T, s Store) {
+		mustPut(t, s, "api", "v1.0.0")
+		mustPut(t, s, "cli", "v1.0.0")
+		err := s.Update(func(f *VFile) error {
+			f.Set("api", Vers{Prefix: "v", Major: 2})
+			f.Set("web", Vers{Prefix: "v", Major: 1})
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("Update gave %v, want %v", err, boom)
+		}
+		wantVersion(t, s, "api", "v1.0.0")
+		if _, err := s.Get("web"); !errors.Is(err, ErrEntryNotFound) {
+			t.Errorf("a failed Update added an entry")
+		}
+
+		if err := s.Update(func(f *VFile) error { return f.Group("tools", "api", "cli") }); err != nil {
+			t.Fatal(err)
+		}
+		err = s.Update(func(f *VFile) error {
+			f.Set("api", Vers{Prefix: "v", Major: 2})
+			return nil
+		})
+		if !errors.Is(err, ErrDiverged) {
+			t.Fatalf("Update diverging a group gave %v, want ErrDiverged", err)
+		}
+		wantVersion(t, s, "api", "v1.0.0")
+		if err := s.Put("cli", Vers{Prefix: "v", Major: 2}); !errors.Is(err, ErrDiverged) {
+			t.Errorf("Put diverging a group gave %v, want ErrDiverged", err)
+		}
+	})
+}
+
+func TestFileUpdateFailsNotCached(t *testing.T) {
+	v, done := fileStore(t, 1)
+	defer done()
+	mustPut(t, v, "api", "v1.0.0")
+	v.Update(func(f *VFile) error {
+		f.Set("api", Vers{Prefix: "v", Major: 2})
+		return errors.New("boom")
+	})
+	if s := v.String("api"); s != "v1.0.0" {
+		t.Errorf("String after a failed Update is %s, want v1.0.0", s)
+	}
+	if ve, _ := v.Entry("api"); ve.String() != "v1.0.0" {
+		t.Errorf("Entry after a failed Update is %s, want v1.0.0", ve)
+	}
+	if ve := v.Entries()["api"]; ve == nil || ve.String() != "v1.0.0" {
+		t.Errorf("Entries after a failed Update has %v, want v1.0.0", ve)
+	}
+}
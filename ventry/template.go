@@ -0,0 +1,182 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Template output formats, "template=<text>" or "template-file=<path>".
+const (
+	TemplateFormat     = "template="
+	TemplateFileFormat = "template-file="
+)
+
+// TemplateData is the data model templates are executed against.
+//
+//	{{.Name}}        entry name                 api
+//	{{.Prefix}}      prefix                     v
+//	{{.Major}}       major number               1
+//	{{.Minor}}       minor number               2
+//	{{.Patch}}       patch number               3
+//	{{.Suffix}}      suffix                     -rc.1+b5
+//	{{.PreRelease}}  pre-release of the suffix  rc.1
+//	{{.Build}}       build of the suffix        b5
+//	{{.Version}}     full version               v1.2.3-rc.1+b5
+//	{{.Semver}}      version without prefix     1.2.3-rc.1+b5
+//	{{.Vers}}        the ventry.Vers itself
+type TemplateData struct {
+	Name       string
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Suffix     string
+	PreRelease string
+	Build      string
+	Version    string
+	Semver     string
+	Vers       Vers
+}
+
+// NewTemplateData returns the template data for an entry.
+func NewTemplateData(name string, ve Vers) TemplateData {
+	return TemplateData{
+		Name:       name,
+		Prefix:     ve.Prefix,
+		Major:      ve.Major,
+		Minor:      ve.Minor,
+		Patch:      ve.Patch,
+		Suffix:     ve.Suffix,
+		PreRelease: ve.PreRelease(),
+		Build:      ve.Build(),
+		Version:    ve.String(),
+		Semver:     ve.Semver(),
+		Vers:       ve,
+	}
+}
+
+// TemplateFuncs are the helper functions available to templates:
+//
+//	upper, lower                 change case
+//	replace OLD NEW S            replace all OLD in S with NEW
+//	trimPrefix P S, trimSuffix   strip P from S
+//	env NAME                     environment variable
+//	major, minor, patch V        number parts of the version string V
+//	prerelease, build V          suffix parts of the version string V
+//	semver V                     V without its prefix
+//	version                      the entry without its prefix ({{.Semver}})
+//
+// version is bound to the entry by ExecTemplate, executing the template
+// any other way makes it fail.
+func TemplateFuncs() template.FuncMap {
+	part := func(fn func(Vers) string) func(string) (string, error) {
+		return func(s string) (string, error) {
+			ve, err := Parse(s)
+			if err != nil {
+				return "", err
+			}
+			return fn(ve), nil
+		}
+	}
+	return template.FuncMap{
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"trimPrefix": func(p, s string) string { return strings.TrimPrefix(s, p) },
+		"trimSuffix": func(p, s string) string { return strings.TrimSuffix(s, p) },
+		"env":        os.Getenv,
+		"major":      part(func(v Vers) string { return fmt.Sprint(v.Major) }),
+		"minor":      part(func(v Vers) string { return fmt.Sprint(v.Minor) }),
+		"patch":      part(func(v Vers) string { return fmt.Sprint(v.Patch) }),
+		"prerelease": part(Vers.PreRelease),
+		"build":      part(Vers.Build),
+		"semver":     part(Vers.Semver),
+		"version":    func() (string, error) { return "", errNoEntry },
+	}
+}
+
+// errNoEntry is the error of version outside of ExecTemplate.
+var errNoEntry = errors.New("version is not available here, use the template data")
+
+// NewTemplate parses a template using TemplateFuncs.
+func NewTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(TemplateFuncs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s; %w", name, err)
+	}
+	return t, nil
+}
+
+// ExecTemplate runs t for an entry and returns the result.  t itself
+// is not changed, so it can be run for several entries at once.
+func ExecTemplate(t *template.Template, name string, ve Vers) (string, error) {
+	var buf bytes.Buffer
+
+	d := NewTemplateData(name, ve)
+	c, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	c.Funcs(template.FuncMap{"version": func() string { return d.Semver }})
+	if err := c.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// formatTemplate returns the template for a template or template-file
+// output format, nil for any other format.
+func formatTemplate(format string) (*template.Template, error) {
+	switch {
+	case strings.HasPrefix(format, TemplateFormat):
+		return NewTemplate("template", strings.TrimPrefix(format, TemplateFormat))
+	case strings.HasPrefix(format, TemplateFileFormat):
+		p := strings.TrimPrefix(format, TemplateFileFormat)
+		text, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return NewTemplate(p, string(text))
+	}
+	return nil, nil
+}
+
+// renderTemplate writes an entry through t, making sure each entry
+// ends up on its own line.
+func renderTemplate(w io.Writer, t *template.Template, name string, ve Vers) error {
+	out, err := ExecTemplate(t, name, ve)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
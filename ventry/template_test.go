@@ -0,0 +1,169 @@
+package ventry
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestExecTemplate(t *testing.T) {
+	os.Setenv("VERS_TEST_TEMPLATE", "from-env")
+	defer os.Unsetenv("VERS_TEST_TEMPLATE")
+	ve := Vers{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Suffix: "-rc.1+b5"}
+
+	tests := []struct {
+		text string
+		want string
+		fail bool
+	}{
+		// the data model
+		{text: "{{.Name}}", want: "api"},
+		{text: "{{.Prefix}}", want: "v"},
+		{text: "{{.Major}}.{{.Minor}}.{{.Patch}}", want: "1.2.3"},
+		{text: "{{.Suffix}}", want: "-rc.1+b5"},
+		{text: "{{.PreRelease}}", want: "rc.1"},
+		{text: "{{.Build}}", want: "b5"},
+		{text: "{{.Version}}", want: "v1.2.3-rc.1+b5"},
+		{text: "{{.Semver}}", want: "1.2.3-rc.1+b5"},
+		{text: "{{.Vers.Major}}", want: "1"},
+		{text: "{{.Nope}}", fail: true},
+
+		// the helpers
+		{text: "{{upper .Name}}", want: "API"},
+		{text: `{{"API" | lower}}`, want: "api"},
+		{text: `{{replace "." "_" .Semver}}`, want: "1_2_3-rc_1+b5"},
+		{text: `{{trimPrefix "v" .Version}}`, want: "1.2.3-rc.1+b5"},
+		{text: `{{trimSuffix "+b5" .Version}}`, want: "v1.2.3-rc.1"},
+		{text: `{{env "VERS_TEST_TEMPLATE"}}`, want: "from-env"},
+		{text: `{{env "VERS_TEST_UNSET"}}`, want: ""},
+		{text: `{{major "v4.5.6-x"}}/{{minor "v4.5.6-x"}}/{{patch "v4.5.6-x"}}`, want: "4/5/6"},
+		{text: `{{prerelease "4.5.6-beta+42"}} {{build "4.5.6-beta+42"}}`, want: "beta 42"},
+		{text: `{{semver "release-4.5.6"}}`, want: "4.5.6"},
+		{text: `{{major "four"}}`, fail: true},
+		{text: "api/v{{version}}", want: "api/v1.2.3-rc.1+b5"},
+		{text: "{{version | upper}}", want: "1.2.3-RC.1+B5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tmpl, err := NewTemplate("test", tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ExecTemplate(tmpl, "api", ve)
+			if tt.fail {
+				if err == nil {
+					t.Errorf("= %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("= %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateVersionOutsideExec(t *testing.T) {
+	tmpl, err := NewTemplate("test", "v{{version}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewTemplateData("api", Vers{Major: 1})); !errors.Is(err, errNoEntry) {
+		t.Errorf("Execute = %q, %v, want errNoEntry", buf.String(), err)
+	}
+}
+
+func TestExecTemplateConcurrent(t *testing.T) {
+	tmpl, err := NewTemplate("test", "{{.Name}} {{version}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name, want := fmt.Sprint("e", i), fmt.Sprintf("e%d %d.0.0", i, i)
+			if got, err := ExecTemplate(tmpl, name, Vers{Major: i}); err != nil || got != want {
+				errs <- fmt.Errorf("%s: %q, %v, want %q", name, got, err, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestTemplateFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vers-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "entry.tmpl")
+	if err := ioutil.WriteFile(file, []byte("{{.Name}}={{.Semver}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ents := Entries{"api": &Vers{Prefix: "v", Major: 1}, "web": &Vers{Major: 2, Minor: 1}}
+
+	tests := []struct {
+		format string
+		want   string
+		err    bool
+	}{
+		{format: TemplateFormat + "{{.Name}} {{.Version}}", want: "api v1.0.0\nweb 2.1.0\n"},
+		{format: TemplateFormat + "{{.Name}}\n", want: "api\nweb\n"},
+		{format: TemplateFileFormat + file, want: "api=1.0.0\nweb=2.1.0\n"},
+		{format: TemplateFileFormat + filepath.Join(dir, "missing.tmpl"), err: true},
+		{format: TemplateFormat + "{{.Name", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := RenderAll(&buf, ents, tt.format)
+			if tt.err {
+				if err == nil {
+					t.Errorf("= %q, want an error", buf.String())
+				}
+				return
+			}
+			if err != nil || buf.String() != tt.want {
+				t.Errorf("= %q, %v, want %q", buf.String(), err, tt.want)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, ents, "web", TemplateFileFormat+file); err != nil || buf.String() != "web=2.1.0\n" {
+		t.Errorf("Render web = %q, %v", buf.String(), err)
+	}
+}
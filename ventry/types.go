@@ -1,6 +1,11 @@
 package ventry
 
-import "github.com/gofrs/flock"
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/flock"
+)
 
 // Vers tracks single program version.
 type Vers struct {
@@ -10,19 +15,47 @@ type Vers struct {
 	Major  int
 	Minor  int
 	Patch  int
+
+	// Changed and By record when and by whom the entry was last set.
+	Changed *time.Time `json:",omitempty" yaml:",omitempty"`
+	By      string     `json:",omitempty" yaml:",omitempty"`
+}
+
+// String renders the version as prefix, numbers and suffix (v1.2.3-rc1)
+func (v Vers) String() string {
+	return fmt.Sprintf("%s%d.%d.%d%s", v.Prefix, v.Major, v.Minor, v.Patch, v.Suffix)
+}
+
+// Semver renders the version without its prefix (1.2.3-rc1)
+func (v Vers) Semver() string {
+	return fmt.Sprintf("%d.%d.%d%s", v.Major, v.Minor, v.Patch, v.Suffix)
 }
 
 // Entries one or more versions.
 type Entries map[string]*Vers
 
-// Rollback is how we keep a history (for now a single item)
+// Rollback is how we keep a history (the most recent item)
 type Rollback map[string]Vers
 
+// History holds values older than the rollback entry, newest first.
+type History map[string][]Vers
+
+// Deps maps an entry to the entries it depends on.
+type Deps map[string][]string
+
+// Groups maps a group name to its members.
+type Groups map[string][]string
+
 // VFile represents the format we write to the version file it
 // has the current version and a history/rollback hash and array
 type VFile struct {
 	Version Entries
 	Prev    Rollback
+	History History `json:",omitempty" yaml:",omitempty"`
+	Deps    Deps    `json:",omitempty" yaml:",omitempty"`
+	Groups  Groups  `json:",omitempty" yaml:",omitempty"`
+
+	keep int
 }
 
 // Vers is a file locked instance of entries
@@ -30,4 +63,5 @@ type VEntry struct {
 	lck  *flock.Flock
 	path string
 	ent  *VFile
+	keep int
 }
@@ -0,0 +1,61 @@
+package vgit
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Conventional is a commit message in the Conventional Commits form
+// "type(scope)!: description".
+type Conventional struct {
+	Type        string
+	Scope       string
+	Description string
+	// Breaking is set by a "!" after the type or scope or a
+	// BREAKING CHANGE footer.
+	Breaking bool
+}
+
+var (
+	conventionalRe = regexp.MustCompile(`^([A-Za-z][\w-]*)(?:\(([^()]*)\))?(!)?: +(.+)$`)
+	breakingRe     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+// ParseConventional parses a commit message, reporting false if it is
+// not a conventional commit.
+func ParseConventional(msg string) (Conventional, bool) {
+	subject := msg
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		subject = msg[:i]
+	}
+	m := conventionalRe.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return Conventional{}, false
+	}
+	return Conventional{
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[2],
+		Description: m[4],
+		Breaking:    len(m[3]) != 0 || breakingRe.MatchString(msg[len(subject):]),
+	}, true
+}
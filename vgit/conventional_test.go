@@ -0,0 +1,84 @@
+package vgit
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "testing"
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want Conventional
+		ok   bool
+	}{
+		{"feat: add the thing", Conventional{Type: "feat", Description: "add the thing"}, true},
+		{"fix(api): off by one", Conventional{Type: "fix", Scope: "api", Description: "off by one"}, true},
+		{"feat!: drop v1", Conventional{Type: "feat", Description: "drop v1", Breaking: true}, true},
+		{"refactor(api)!: rename", Conventional{Type: "refactor", Scope: "api", Description: "rename", Breaking: true}, true},
+		{"Feat: capital type", Conventional{Type: "feat", Description: "capital type"}, true},
+		{"chore-deps: bump", Conventional{Type: "chore-deps", Description: "bump"}, true},
+		{"wibble: an unknown type", Conventional{Type: "wibble", Description: "an unknown type"}, true},
+		{"feat: new flag\n\nBREAKING CHANGE: the old one is gone", Conventional{Type: "feat", Description: "new flag", Breaking: true}, true},
+		{"fix: typo\n\nBREAKING-CHANGE: so it is", Conventional{Type: "fix", Description: "typo", Breaking: true}, true},
+		{"fix: typo\n\nmentions a BREAKING CHANGE: in passing", Conventional{Type: "fix", Description: "typo"}, true},
+		{"BREAKING CHANGE: in the subject", Conventional{}, false},
+		{"Merge branch 'main'", Conventional{}, false},
+		{"feat:no space", Conventional{}, false},
+		{"feat(a)(b): two scopes", Conventional{}, false},
+		{"", Conventional{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseConventional(tt.msg)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseConventional(%q) = %+v, %v, want %+v, %v", tt.msg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	r := newTestRepo(t)
+	defer r.close()
+	r.commit("a.txt", "1", "first")
+	r.git("tag", "v1.0.0")
+	r.commit("a.txt", "2", "feat: second\n\nwith a body")
+	r.commit("b/b.txt", "3", "fix: third")
+
+	all, err := r.Log("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Message != "fix: third" || all[2].Message != "first" {
+		t.Errorf("Log(\"\") = %+v, want the 3 commits newest first", all)
+	}
+	since, err := r.Log("v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since) != 2 || since[1].Message != "feat: second\n\nwith a body" {
+		t.Errorf("Log(v1.0.0) = %+v, want the 2 commits after the tag", since)
+	}
+	scoped, err := r.Log("v1.0.0", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scoped) != 1 || scoped[0].Message != "fix: third" {
+		t.Errorf("Log(v1.0.0, b) = %+v, want the commit touching b", scoped)
+	}
+}
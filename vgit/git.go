@@ -0,0 +1,292 @@
+// Package vgit runs the git commands vers needs against a local
+// repository.
+package vgit
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+var (
+	// ErrNotRepo the directory is not in a git work tree.
+	ErrNotRepo = errors.New("not a git repository")
+	// ErrDirty the work tree has uncommitted changes.
+	ErrDirty = errors.New("work tree has uncommitted changes")
+	// ErrTagExists the tag is already in the repository.
+	ErrTagExists = errors.New("tag already exists")
+	// ErrNoTag no tag describes the commit.
+	ErrNoTag = errors.New("no matching tag")
+)
+
+// Description places HEAD relative to the nearest tag.
+type Description struct {
+	Tag string
+	// Distance is the number of commits since Tag.
+	Distance int
+	// Hash is the abbreviated hash of HEAD.
+	Hash  string
+	Dirty bool
+}
+
+// Repo is a local git repository.
+type Repo struct {
+	// Dir is any directory in the work tree ("" is the current one).
+	Dir string
+}
+
+// Open returns the repository containing dir.
+func Open(dir string) (*Repo, error) {
+	r := &Repo{Dir: dir}
+	if _, err := r.Git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%s; %w", dir, ErrNotRepo)
+	}
+	return r, nil
+}
+
+// Git runs git with args in the repository returning its output with
+// the trailing newline removed.
+func (r *Repo) Git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.Command("git", args...)
+	c.Dir = r.Dir
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	log.Debugf("Git(): %s", strings.Join(args, " "))
+	if err := c.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) == 0 {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s; %s", args[0], msg)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// TopLevel returns the root directory of the work tree.
+func (r *Repo) TopLevel() (string, error) {
+	return r.Git("rev-parse", "--show-toplevel")
+}
+
+// HooksDir returns the directory git runs hooks from.
+func (r *Repo) HooksDir() (string, error) {
+	p, err := r.Git("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		// before git 2.31 the path is relative to the work tree
+		if p, err = r.Git("rev-parse", "--git-path", "hooks"); err != nil {
+			return "", err
+		}
+	}
+	if !filepath.IsAbs(p) {
+		top, err := r.TopLevel()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(top, p)
+	}
+	return p, nil
+}
+
+// Staged returns the paths, relative to the top of the work tree, of
+// the files staged to be committed.
+func (r *Repo) Staged() ([]string, error) {
+	out, err := r.Git("diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return strings.FieldsFunc(out, func(c rune) bool { return c == 0 }), nil
+}
+
+// Show returns the contents of path (relative to the top of the work
+// tree) at rev, "" being the index.  ok is false if it is not there.
+func (r *Repo) Show(rev, path string) (data []byte, ok bool, err error) {
+	obj := rev + ":" + path
+	if _, err := r.Git("cat-file", "-e", obj); err != nil {
+		return nil, false, nil
+	}
+	out, err := r.Git("cat-file", "blob", obj)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(out), true, nil
+}
+
+// Head returns the full hash of the HEAD commit.
+func (r *Repo) Head() (string, error) {
+	return r.Git("rev-parse", "HEAD")
+}
+
+// ShortHead returns the abbreviated hash of the HEAD commit.
+func (r *Repo) ShortHead() (string, error) {
+	return r.Git("rev-parse", "--short", "HEAD")
+}
+
+// Dirty reports whether the work tree has uncommitted changes
+// (untracked files included).
+func (r *Repo) Dirty() (bool, error) {
+	out, err := r.Git("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return len(out) != 0, nil
+}
+
+// Tags returns the tags matching the glob pattern match.
+func (r *Repo) Tags(match string) ([]string, error) {
+	out, err := r.Git("tag", "--list", match)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// TagExists reports whether the tag is in the repository.
+func (r *Repo) TagExists(name string) (bool, error) {
+	if _, err := r.Git("check-ref-format", "refs/tags/"+name); err != nil {
+		return false, fmt.Errorf("%q is not a valid tag name", name)
+	}
+	_, err := r.Git("rev-parse", "-q", "--verify", "refs/tags/"+name)
+	return err == nil, nil
+}
+
+// Tag creates an annotated (or, with sign, a signed) tag of HEAD.
+func (r *Repo) Tag(name, msg string, sign bool) error {
+	ok, err := r.TagExists(name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return fmt.Errorf("%s; %w", name, ErrTagExists)
+	}
+	kind := "-a"
+	if sign {
+		kind = "-s"
+	}
+	_, err = r.Git("tag", kind, "-m", msg, name)
+	return err
+}
+
+// Changes returns the number of commits reachable from HEAD but not
+// from since (every commit if since is "") that touch the pathspecs.
+func (r *Repo) Changes(since string, pathspecs ...string) (int, error) {
+	rev := "HEAD"
+	if len(since) != 0 {
+		rev = since + "..HEAD"
+	}
+	out, err := r.Git(append([]string{"rev-list", "--count", rev, "--"}, pathspecs...)...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
+// Date returns the commit date of rev as YYYY-MM-DD.
+func (r *Repo) Date(rev string) (string, error) {
+	return r.Git("log", "-1", "--format=%cd", "--date=short", rev)
+}
+
+// Commit commits the given files with msg.
+func (r *Repo) Commit(msg string, paths ...string) error {
+	if _, err := r.Git(append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	_, err := r.Git(append([]string{"commit", "-q", "-m", msg, "--"}, paths...)...)
+	return err
+}
+
+// Describe finds the nearest tag reachable from HEAD that matches the
+// glob pattern match.
+func (r *Repo) Describe(match string) (Description, error) {
+	var d Description
+
+	out, err := r.Git("describe", "--tags", "--long", "--dirty", "--abbrev=7", "--match", match)
+	if err != nil {
+		if strings.Contains(err.Error(), "No names found") || strings.Contains(err.Error(), "No tags can describe") ||
+			strings.Contains(err.Error(), "cannot describe") {
+			return d, fmt.Errorf("%s; %w", match, ErrNoTag)
+		}
+		return d, err
+	}
+	// tag-distance-gHASH[-dirty], the tag may have dashes of its own
+	if strings.HasSuffix(out, "-dirty") {
+		d.Dirty = true
+		out = strings.TrimSuffix(out, "-dirty")
+	}
+	i := strings.LastIndex(out, "-g")
+	j := -1
+	if i > 0 {
+		j = strings.LastIndex(out[:i], "-")
+	}
+	if j < 0 {
+		return d, fmt.Errorf("git describe; unexpected output %q", out)
+	}
+	if d.Distance, err = strconv.Atoi(out[j+1 : i]); err != nil {
+		return d, fmt.Errorf("git describe; unexpected output %q", out)
+	}
+	d.Tag, d.Hash = out[:j], out[i+2:]
+	return d, nil
+}
+
+// Commit is a commit message and its abbreviated hash.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Log returns the commits reachable from HEAD but not from since
+// (every commit if since is ""), newest first.  With pathspecs only
+// the commits touching them are returned.
+func (r *Repo) Log(since string, pathspecs ...string) ([]Commit, error) {
+	return r.LogRange(since, "HEAD", pathspecs...)
+}
+
+// LogRange returns the commits reachable from to but not from since
+// (every commit if since is ""), newest first.  With pathspecs only
+// the commits touching them are returned.
+func (r *Repo) LogRange(since, to string, pathspecs ...string) ([]Commit, error) {
+	rev := to
+	if len(since) != 0 {
+		rev = since + ".." + to
+	}
+	args := append([]string{"log", "--format=%h%x1f%B%x1e", rev, "--"}, pathspecs...)
+	out, err := r.Git(args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, rec := range strings.Split(out, "\x1e") {
+		f := strings.SplitN(strings.TrimLeft(rec, "\n"), "\x1f", 2)
+		if len(f) != 2 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: f[0], Message: strings.TrimSpace(f[1])})
+	}
+	return commits, nil
+}
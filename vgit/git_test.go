@@ -0,0 +1,188 @@
+// Package vgit runs the git commands vers needs against a local
+// repository.
+package vgit
+
+// Copyright © 2020 Robert B Gordon <rbg@h9k.io>
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testRepo is a git repository in a temporary directory.
+type testRepo struct {
+	*Repo
+	t *testing.T
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := (&Repo{}).Git("--version"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "vgit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRepo{Repo: &Repo{Dir: dir}, t: t}
+	r.git("init", "-q")
+	r.git("config", "user.name", "vers")
+	r.git("config", "user.email", "vers@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	r.git("config", "tag.gpgsign", "false")
+	return r
+}
+
+func (r *testRepo) close() {
+	os.RemoveAll(r.Dir)
+}
+
+// git runs git in the repository, failing the test on an error.
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	out, err := r.Git(args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return out
+}
+
+// commit writes data to file and commits it with msg.
+func (r *testRepo) commit(file, data, msg string) {
+	r.t.Helper()
+	p := filepath.Join(r.Dir, file)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := r.Commit(msg, file); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	r := newTestRepo(t)
+	defer r.close()
+	if err := os.Mkdir(filepath.Join(r.Dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(filepath.Join(r.Dir, "sub")); err != nil {
+		t.Error(err)
+	}
+
+	dir, err := ioutil.TempDir("", "vgit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := Open(dir); !errors.Is(err, ErrNotRepo) {
+		t.Errorf("Open outside a repository: %v, want ErrNotRepo", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	r := newTestRepo(t)
+	defer r.close()
+	r.commit("a", "1", "first")
+	if _, err := r.Describe("v*"); !errors.Is(err, ErrNoTag) {
+		t.Fatalf("Describe without tags: %v, want ErrNoTag", err)
+	}
+	r.git("tag", "-a", "-m", "v1.0.0", "v1.0.0")
+	r.git("tag", "-a", "-m", "odd", "api/v1.0.0-2-gabc1234")
+	r.git("tag", "-a", "-m", "dashes", "web-rc-1.0.0")
+
+	short := func() string { return r.git("rev-parse", "--short=7", "HEAD") }
+	tests := []struct {
+		name  string
+		setup func()
+		match string
+		want  Description
+	}{
+		{"on the tag", func() {}, "v*", Description{Tag: "v1.0.0"}},
+		// a tag that looks like describe output itself
+		{"tag with -N-g", func() {}, "api/*", Description{Tag: "api/v1.0.0-2-gabc1234"}},
+		{"tag with dashes", func() {}, "web-*", Description{Tag: "web-rc-1.0.0"}},
+		{"commits since", func() {
+			r.commit("a", "2", "second")
+			r.commit("a", "3", "third")
+		}, "v*", Description{Tag: "v1.0.0", Distance: 2}},
+		{"commits since, odd tag", func() {}, "api/*", Description{Tag: "api/v1.0.0-2-gabc1234", Distance: 2}},
+		{"dirty", func() {
+			if err := ioutil.WriteFile(filepath.Join(r.Dir, "a"), []byte("4"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}, "v*", Description{Tag: "v1.0.0", Distance: 2, Dirty: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			tt.want.Hash = short()
+			d, err := r.Describe(tt.match)
+			if err != nil || d != tt.want {
+				t.Errorf("Describe(%q) = %+v, %v, want %+v", tt.match, d, err, tt.want)
+			}
+		})
+	}
+	if _, err := r.Describe("nope*"); !errors.Is(err, ErrNoTag) {
+		t.Errorf("Describe(nope*): %v, want ErrNoTag", err)
+	}
+}
+
+func TestTag(t *testing.T) {
+	r := newTestRepo(t)
+	defer r.close()
+	r.commit("a", "1", "first")
+
+	if err := r.Tag("api/v1.0.0", "api release v1.0.0", false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.TagExists("api/v1.0.0"); err != nil || !ok {
+		t.Errorf("TagExists = %v, %v, want true", ok, err)
+	}
+	if typ := r.git("cat-file", "-t", "api/v1.0.0"); typ != "tag" {
+		t.Errorf("the tag is a %s, want an annotated tag", typ)
+	}
+	if msg := r.git("tag", "-l", "--format=%(contents:subject)", "api/v1.0.0"); msg != "api release v1.0.0" {
+		t.Errorf("the tag message is %q", msg)
+	}
+	if err := r.Tag("api/v1.0.0", "again", false); !errors.Is(err, ErrTagExists) {
+		t.Errorf("Tag again: %v, want ErrTagExists", err)
+	}
+	if err := r.Tag("bad..name", "x", false); err == nil {
+		t.Error("Tag bad..name: no error")
+	}
+	if ok, err := r.TagExists("v2"); err != nil || ok {
+		t.Errorf("TagExists(v2) = %v, %v, want false", ok, err)
+	}
+
+	if dirty, err := r.Dirty(); err != nil || dirty {
+		t.Errorf("Dirty = %v, %v, want false", dirty, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.Dir, "new"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, err := r.Dirty(); err != nil || !dirty {
+		t.Errorf("Dirty with an untracked file = %v, %v, want true", dirty, err)
+	}
+}